@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// abiParams converts composite params (arrays and tuples) and integers of unspecified size
+// into values of the exact Go types expected by the ABI packer for inputs of the method,
+// an empty method name stands for the constructor. Other params are passed as-is.
+func abiParams(contractABI abi.ABI, method string, params []interface{}) ([]interface{}, error) {
+	inputs := contractABI.Constructor.Inputs
+	if len(method) > 0 {
+		m, ok := contractABI.Methods[method]
+		if !ok {
+			// the packer will report
+			return params, nil
+		}
+		inputs = m.Inputs
+	}
+	if len(inputs) != len(params) {
+		// the packer will report
+		return params, nil
+	}
+	converted := make([]interface{}, len(params))
+	for i, param := range params {
+		switch param.(type) {
+		case *model.CompositeParam, *model.MissingArg, *big.Int, common.Hash:
+		default:
+			converted[i] = param
+			continue
+		}
+		v, err := abiValue(inputs[i].Type, param)
+		if err != nil {
+			err = fmt.Errorf("param %d (%s): %v", i, inputs[i].Type.String(), err)
+			return nil, err
+		}
+		converted[i] = v.Interface()
+	}
+	return converted, nil
+}
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+func abiValue(typ abi.Type, v interface{}) (reflect.Value, error) {
+	if missing, ok := v.(*model.MissingArg); ok {
+		return reflect.Value{}, missing
+	}
+	if value := reflect.ValueOf(v); value.IsValid() && value.Type() == typ.Type {
+		// e.g. a list returned by the earlier step
+		return value, nil
+	}
+	switch typ.T {
+	case abi.SliceTy, abi.ArrayTy:
+		composite, ok := v.(*model.CompositeParam)
+		if !ok {
+			err := fmt.Errorf("expected a list, got %T", v)
+			return reflect.Value{}, err
+		}
+		var out reflect.Value
+		if typ.T == abi.SliceTy {
+			out = reflect.MakeSlice(typ.Type, len(composite.Elems), len(composite.Elems))
+		} else if len(composite.Elems) != typ.Size {
+			err := fmt.Errorf("expected %d elements, got %d", typ.Size, len(composite.Elems))
+			return reflect.Value{}, err
+		} else {
+			out = reflect.New(typ.Type).Elem()
+		}
+		for i, elem := range composite.Elems {
+			elemValue, err := abiValue(*typ.Elem, elem)
+			if err != nil {
+				err = fmt.Errorf("element %d: %v", i, err)
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(elemValue)
+		}
+		return out, nil
+	case abi.TupleTy:
+		composite, ok := v.(*model.CompositeParam)
+		if !ok {
+			err := fmt.Errorf("expected a tuple, got %T", v)
+			return reflect.Value{}, err
+		} else if len(composite.Elems) != len(typ.TupleElems) {
+			err := fmt.Errorf("expected %d tuple components, got %d", len(typ.TupleElems), len(composite.Elems))
+			return reflect.Value{}, err
+		}
+		// fields of the struct type follow the order of tuple components
+		out := reflect.New(typ.Type).Elem()
+		for i, elemType := range typ.TupleElems {
+			elemValue, err := abiValue(*elemType, composite.Elems[i])
+			if err != nil {
+				err = fmt.Errorf("component %s: %v", typ.TupleRawNames[i], err)
+				return reflect.Value{}, err
+			}
+			out.Field(i).Set(elemValue)
+		}
+		return out, nil
+	}
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		err := errors.New("value is not set")
+		return reflect.Value{}, err
+	} else if value.Type() == typ.Type {
+		return value, nil
+	} else if value.Type() == bigIntType {
+		// numeric params of unspecified size are parsed as big ints
+		return bigIntValue(typ, v.(*big.Int))
+	} else if value.Type().ConvertibleTo(typ.Type) && value.Kind() == typ.Type.Kind() {
+		return value.Convert(typ.Type), nil
+	}
+	err := fmt.Errorf("cannot use %T as %s", v, typ.String())
+	return reflect.Value{}, err
+}
+
+func bigIntValue(typ abi.Type, v *big.Int) (reflect.Value, error) {
+	out := reflect.New(typ.Type).Elem()
+	switch typ.Type.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !v.IsInt64() || out.OverflowInt(v.Int64()) {
+			err := fmt.Errorf("value %s overflows %s", v.String(), typ.String())
+			return reflect.Value{}, err
+		}
+		out.SetInt(v.Int64())
+		return out, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Sign() < 0 || !v.IsUint64() || out.OverflowUint(v.Uint64()) {
+			err := fmt.Errorf("value %s overflows %s", v.String(), typ.String())
+			return reflect.Value{}, err
+		}
+		out.SetUint(v.Uint64())
+		return out, nil
+	}
+	err := fmt.Errorf("cannot use %T as %s", v, typ.String())
+	return reflect.Value{}, err
+}
@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// awaitedTx is a transaction being awaited, along with its replacements with bumped fees,
+// any of them may get mined.
+type awaitedTx struct {
+	hashes        []common.Hash
+	confirmations uint64
+	bumpedAt      time.Time
+
+	// receipt is set once mined, it is reset if the transaction is reorganized out
+	receipt *txReceipt
+	done    bool
+	err     error
+}
+
+func (e *Executor) awaitTx(ctx model.AppContext, v interface{}, confirmations uint64) (*txReceipt, error) {
+	receipts, errs := e.awaitTxs(ctx, []interface{}{v}, []uint64{confirmations})
+	return receipts[0], errs[0]
+}
+
+// awaitTxs waits for the transactions to get mined and confirmed. The node is checked once per new block,
+// the receipts of all outstanding transactions are fetched by a single batch request. The receipt of each
+// mined transaction is returned, along with the error if it has failed.
+func (e *Executor) awaitTxs(ctx model.AppContext, values []interface{}, confirmations []uint64) ([]*txReceipt, []error) {
+	receipts := make([]*txReceipt, len(values))
+	errs := make([]error, len(values))
+	awaited := make([]*awaitedTx, len(values))
+	for i, v := range values {
+		txHash, err := parseTxHash(v)
+		if err == nil {
+			// fetched as-is, since the vendored go-ethereum doesn't support dynamic-fee transactions
+			_, err = e.transactionByHash(ctx, txHash)
+		}
+		awaited[i] = &awaitedTx{
+			hashes:        []common.Hash{txHash},
+			confirmations: confirmations[i],
+			bumpedAt:      time.Now(),
+			done:          err != nil,
+			err:           err,
+		}
+	}
+	blocksC, stopFn := e.newBlocks(ctx)
+	defer stopFn()
+	for !allDone(awaited) {
+		select {
+		case <-blocksC:
+			if err := e.checkAwaited(ctx, awaited); err != nil {
+				log.WithError(err).Warningln("error while checking the transaction status")
+			}
+		case <-ctx.Done():
+			for _, tx := range awaited {
+				if !tx.done {
+					tx.done = true
+					tx.err = ctx.Err()
+				}
+			}
+		}
+	}
+	for i, tx := range awaited {
+		if tx.err != nil {
+			errs[i] = tx.err
+			continue
+		}
+		receipts[i], errs[i] = e.checkReceipt(ctx, tx.receipt)
+	}
+	return receipts, errs
+}
+
+func parseTxHash(v interface{}) (common.Hash, error) {
+	if deployment, ok := v.(*Deployment); ok {
+		return deployment.TxHash, nil
+	}
+	value, ok := v.(string)
+	if !ok {
+		err := fmt.Errorf("unknown result type: %T", v)
+		return common.Hash{}, err
+	}
+	if strings.HasPrefix(value, "tx:") {
+		value = value[3:]
+	} else if !strings.HasPrefix(value, "0x") {
+		err := fmt.Errorf("value is not a hex-string: %s", value)
+		return common.Hash{}, err
+	}
+	return common.HexToHash(value), nil
+}
+
+func allDone(awaited []*awaitedTx) bool {
+	for _, tx := range awaited {
+		if !tx.done {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAwaited fetches the receipts of outstanding transactions and the latest block number in a single batch,
+// then ensures that the blocks of confirmed transactions are still canonical, and bumps fees of the pending ones.
+func (e *Executor) checkAwaited(ctx model.AppContext, awaited []*awaitedTx) error {
+	var latest hexutil.Uint64
+	batch := []rpc.BatchElem{{
+		Method: "eth_blockNumber",
+		Result: &latest,
+	}}
+	receipts := make(map[common.Hash]**txReceipt)
+	for _, tx := range awaited {
+		if tx.done {
+			continue
+		}
+		for _, hash := range tx.hashes {
+			var receipt *txReceipt
+			receipts[hash] = &receipt
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hash},
+				Result: &receipt,
+			})
+		}
+	}
+	if err := e.ethRPC.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return elem.Error
+		}
+	}
+
+	var confirmed []*awaitedTx
+	for _, tx := range awaited {
+		if tx.done {
+			continue
+		}
+		var mined *txReceipt
+		for _, hash := range tx.hashes {
+			if receipt := *receipts[hash]; receipt != nil {
+				mined = receipt
+				break
+			}
+		}
+		if tx.receipt != nil && (mined == nil || mined.BlockHash != tx.receipt.BlockHash) {
+			log.WithFields(log.Fields{
+				"tx":    strings.ToLower(tx.receipt.TxHash.Hex()),
+				"block": uint64(tx.receipt.BlockNumber),
+			}).Warningln("transaction has been reorganized out, awaiting it again")
+			tx.bumpedAt = time.Now()
+		}
+		tx.receipt = mined
+		if mined == nil {
+			e.bumpAwaited(ctx, tx)
+			continue
+		} else if tx.confirmations <= 1 {
+			tx.done = true
+			continue
+		}
+		if uint64(latest)+1 >= uint64(mined.BlockNumber)+tx.confirmations {
+			confirmed = append(confirmed, tx)
+		}
+	}
+	if len(confirmed) == 0 {
+		return nil
+	}
+
+	batch = make([]rpc.BatchElem, 0, len(confirmed))
+	headers := make([]*rpcHeader, len(confirmed))
+	for i, tx := range confirmed {
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{tx.receipt.BlockNumber, false},
+			Result: &headers[i],
+		})
+	}
+	if err := e.ethRPC.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+	for i, tx := range confirmed {
+		if batch[i].Error != nil {
+			return batch[i].Error
+		} else if headers[i] != nil && headers[i].Hash == tx.receipt.BlockHash {
+			tx.done = true
+		}
+		// otherwise the receipt will be gone or updated on the next check
+	}
+	return nil
+}
+
+// bumpAwaited resubmits the pending transaction with bumped fees, once per gasBumpInterval.
+func (e *Executor) bumpAwaited(ctx model.AppContext, tx *awaitedTx) {
+	bumpInterval, _ := e.root.Config.GasBumpIntervalDuration()
+	if bumpInterval <= 0 || time.Since(tx.bumpedAt) < bumpInterval {
+		return
+	}
+	newTxHash, err := e.replaceTx(ctx, tx.hashes[len(tx.hashes)-1], false)
+	if err != nil {
+		log.WithError(err).Warningln("failed to bump fees of the pending transaction, waiting as is")
+	} else {
+		tx.hashes = append(tx.hashes, newTxHash)
+	}
+	tx.bumpedAt = time.Now()
+}
+
+// newBlocks notifies about new blocks, via newHeads subscription if the node supports it (IPC, WebSocket),
+// otherwise the node is polled every pollInterval. The first notification is sent right away.
+func (e *Executor) newBlocks(ctx context.Context) (<-chan struct{}, func()) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	blocksC := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case blocksC <- struct{}{}:
+		default:
+		}
+	}
+	notify()
+	go func() {
+		err := e.followHeads(ctx, notify)
+		if err == nil {
+			return
+		} else if err != rpc.ErrNotificationsUnsupported {
+			log.WithError(err).Warningln("newHeads subscription failed, polling for new blocks")
+		}
+		t := time.NewTicker(e.pollInterval())
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				notify()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocksC, cancelFn
+}
+
+// pollInterval returns the pollInterval of config, or the default one if it's unset.
+func (e *Executor) pollInterval() time.Duration {
+	if interval, err := e.root.Config.PollIntervalDuration(); err == nil && interval > 0 {
+		return interval
+	}
+	interval, _ := model.DefaultConfigSpec.PollIntervalDuration()
+	return interval
+}
+
+// followHeads notifies about new heads received via subscription, until the context is done.
+func (e *Executor) followHeads(ctx context.Context, notify func()) error {
+	headsC := make(chan *rpcHeader, 16)
+	sub, err := e.ethRPC.EthSubscribe(ctx, headsC, "newHeads")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-headsC:
+			notify()
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// Skipped is the result of a target step that has been skipped, since its condition is false.
+type Skipped struct {
+	Condition string
+}
+
+// isSkipped reports whether the step has been skipped, for loop steps — all of its iterations.
+func isSkipped(results []*CommandResult) bool {
+	if len(results) == 0 {
+		return false
+	}
+	for _, result := range results {
+		if _, ok := result.Result.(*Skipped); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// stepCondition evaluates the condition of the target step, true if there is none.
+func (e *Executor) stepCondition(ctx model.AppContext, targetCmd model.TargetCommandSpec) (bool, error) {
+	if len(targetCmd.When) == 0 {
+		return true, nil
+	}
+	steps, _ := ctx.StepResults().(*targetResults)
+	condCtx := ctx.WithStepResults(&conditionResults{
+		ctx:   ctx,
+		exec:  e,
+		steps: steps,
+	})
+	return targetCmd.When.Eval(condCtx, e.root, func(wallet *model.WalletSpec) (*big.Int, error) {
+		return e.ethCli.BalanceAt(ctx, common.HexToAddress(wallet.Address), nil)
+	})
+}
+
+// conditionResults resolves step references of a condition. Besides the results of the steps done,
+// VIEW and CALL commands are run on demand, and WRITE commands provide the address of their instance,
+// which is zero if the instance is not deployed yet.
+type conditionResults struct {
+	ctx   model.AppContext
+	exec  *Executor
+	steps *targetResults
+}
+
+func (c *conditionResults) ResolveStep(ref *model.StepReference) (interface{}, error) {
+	if c.steps.Has(ref.Step) {
+		return c.steps.ResolveStep(ref)
+	}
+	root := c.exec.root
+	var results []*CommandResult
+	if cmdSpec, ok := root.ViewCmds[ref.Step]; ok {
+		results = c.exec.runViewCmd(c.ctx, cmdSpec)
+	} else if cmdSpec, ok := root.CallCmds[ref.Step]; ok {
+		results = c.exec.runCallCmd(c.ctx, cmdSpec)
+	} else if cmdSpec, ok := root.WriteCmds[ref.Step]; ok && ref.Field == model.StepAddressField {
+		if cmdSpec.Instance != nil && !cmdSpec.Instance.IsDeployed() {
+			return common.Address{}, nil
+		}
+		return c.steps.stepAddress(ref.Step)
+	} else {
+		return c.steps.ResolveStep(ref)
+	}
+	onDemand := newTargetResults(root)
+	onDemand.Add(ref.Step, results)
+	return onDemand.ResolveStep(ref)
+}
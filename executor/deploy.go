@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// Deployment is the result of a WRITE command that has deployed a contract instance.
+// The block and gas used are set once the transaction is mined.
+type Deployment struct {
+	Contract string
+	Address  common.Address
+	TxHash   common.Hash
+	Block    uint64
+	GasUsed  uint64
+	// Symbol is set if the contract is a token
+	Symbol string
+
+	instance *model.ContractInstanceSpec
+}
+
+// confirmDeployment updates the deployment from the receipt of its mined transaction,
+// and ensures that the contract code exists at the address.
+func (e *Executor) confirmDeployment(ctx model.AppContext, deployment *Deployment, receipt *txReceipt) error {
+	// the transaction may have been replaced with bumped fees
+	deployment.TxHash = receipt.TxHash
+	deployment.Block = uint64(receipt.BlockNumber)
+	deployment.GasUsed = uint64(receipt.GasUsed)
+	code, err := e.codeAt(ctx, deployment.Address, receipt.BlockNumber)
+	if err != nil {
+		return err
+	} else if len(code) == 0 {
+		err := fmt.Errorf("no contract code at %s after deployment", strings.ToLower(deployment.Address.Hex()))
+		return err
+	}
+	if deployment.instance != nil {
+		deployment.Symbol = deployment.instance.FetchTokenSymbol(ctx)
+	}
+	return nil
+}
+
+// awaitDeployments awaits the deployments made by a command run on its own, so the results report their blocks
+// and gas used, and the code at the addresses is checked. Other transactions of the command are not awaited.
+func (e *Executor) awaitDeployments(ctx model.AppContext, results []*CommandResult) {
+	if ctx.DryRun() || ctx.IsSignOnly() {
+		return
+	}
+	awaitTimeout, _ := e.root.Config.AwaitTimeoutDuration()
+	for _, result := range results {
+		deployment, ok := result.Result.(*Deployment)
+		if !ok || result.Error != nil {
+			continue
+		}
+		awaitCtx, cancelFn := ctx.WithTimeout(awaitTimeout)
+		receipt, err := e.awaitTx(awaitCtx, deployment, e.root.Config.ConfirmationsInt())
+		if receipt == nil {
+			err = awaitFailure(awaitCtx, awaitTimeout, err)
+		}
+		cancelFn()
+		if err == nil {
+			err = e.confirmDeployment(ctx, deployment, receipt)
+		}
+		if err != nil {
+			if receipt != nil {
+				e.resetDeployment(deployment)
+			}
+			result.Error = err
+			log.WithFields(log.Fields{
+				"contract": deployment.Contract,
+				"tx":       strings.ToLower(deployment.TxHash.Hex()),
+			}).WithError(err).Errorln("contract deployment failed")
+			continue
+		}
+		result.Gas = e.gasUsage(ctx, receipt)
+		result.Events = e.decodeEvents(receipt.Logs)
+	}
+}
+
+// resetDeployment clears the address of the instance whose deployment has failed, so the instance can be deployed
+// again, e.g. by a retry of the step. The failed deployment is removed from the state. Deployments without a receipt
+// are kept as they are, since they may still get mined.
+func (e *Executor) resetDeployment(deployment *Deployment) {
+	if state := e.root.DeploymentState(); state != nil {
+		if err := state.Forget(deployment.TxHash.Hex()); err != nil {
+			log.WithError(err).Warningln("failed to save deployment state")
+		}
+	}
+	instance := deployment.instance
+	if instance == nil || !strings.EqualFold(instance.Address, deployment.Address.Hex()) {
+		// deployed again since
+		return
+	}
+	instance.Address = instance.SpecAddress()
+	instance.BoundContract().SetAddress(common.HexToAddress(instance.Address))
+}
+
+// resetInstance clears the address of the instance bound to the address of a cancelled deployment.
+func (e *Executor) resetInstance(address common.Address) {
+	for _, contract := range e.root.Contracts {
+		for _, instance := range contract.Instances {
+			if strings.EqualFold(instance.Address, address.Hex()) {
+				instance.Address = instance.SpecAddress()
+				instance.BoundContract().SetAddress(common.HexToAddress(instance.Address))
+			}
+		}
+	}
+}
+
+func (e *Executor) codeAt(ctx context.Context, address common.Address, block hexutil.Uint64) (hexutil.Bytes, error) {
+	var code hexutil.Bytes
+	if err := e.ethRPC.CallContext(ctx, &code, "eth_getCode", address, block); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
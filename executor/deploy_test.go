@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "deploy-token")
+	if !assert.True(ok) {
+		return
+	}
+	node.Reverted = func(n int) bool {
+		return n == 0
+	}
+	instance := exec.root.Contracts["Token"].Instances[0]
+	results, _ := exec.RunCommand(ctx, "deploy-token")
+	if assert.Len(results, 1) {
+		assert.Error(results[0].Error)
+	}
+	assert.False(instance.IsDeployed())
+	_, ok = exec.root.DeploymentState().Instance("Token", "", 0)
+	assert.False(ok)
+
+	results, _ = exec.RunCommand(ctx, "deploy-token")
+	if !assert.Len(results, 1) || !assert.NoError(results[0].Error) {
+		return
+	}
+	deployment, ok := results[0].Result.(*Deployment)
+	if assert.True(ok) {
+		assert.Equal(crypto.CreateAddress(testAccount, 1), deployment.Address)
+		assert.EqualValues(3, deployment.Block)
+		assert.EqualValues(21000, deployment.GasUsed)
+	}
+	assert.NotNil(results[0].Gas)
+	assert.True(instance.IsDeployed())
+	deployed, ok := exec.root.DeploymentState().Instance("Token", "", 0)
+	if assert.True(ok) {
+		assert.EqualValues(3, deployed.Block)
+	}
+}
+
+func TestCancelPendingDeploy(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "cancel")
+	if !assert.True(ok) {
+		return
+	}
+	node.Pending = func(n int) bool {
+		return n == 0
+	}
+	// sent without awaiting
+	results := exec.runWriteCmd(ctx, exec.root.WriteCmds["deploy-token"])
+	if !assert.Len(results, 1) || !assert.NoError(results[0].Error) {
+		return
+	}
+	deployment, ok := results[0].Result.(*Deployment)
+	if !assert.True(ok) {
+		return
+	}
+	instance := exec.root.Contracts["Token"].Instances[0]
+	assert.True(instance.IsDeployed())
+
+	results = exec.ReplaceTx(ctx, deployment.TxHash.Hex(), true)
+	if assert.Len(results, 1) {
+		assert.NoError(results[0].Error)
+	}
+	assert.Equal(2, node.Sent())
+	assert.False(instance.IsDeployed())
+	_, ok = exec.root.DeploymentState().Instance("Token", "", 0)
+	assert.False(ok)
+}
@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/AtlantPlatform/ethfw"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// Simulation is the outcome of a WRITE command simulated in dry-run mode.
+type Simulation struct {
+	From     common.Address
+	To       *common.Address
+	Nonce    uint64
+	Gas      uint64
+	GasPrice *big.Int
+	// Fee is the estimated gas at the current gas price.
+	Fee *big.Int
+	// ContractAddress is the would-be address of the deployed contract.
+	ContractAddress *common.Address
+	ReturnData      []byte
+}
+
+// NotSimulated is the result of a command that calls a contract deployed earlier in dry-run mode.
+// There is no code at the would-be address of the contract, so the call can't be simulated.
+type NotSimulated struct {
+	Contract string
+	Address  common.Address
+}
+
+func (n *NotSimulated) String() string {
+	return fmt.Sprintf("not simulatable (depends on undeployed %s)", n.Contract)
+}
+
+// dryRunDeploy sets the would-be address of the simulated deployment to the instance, so later steps
+// can reference it, e.g. in params. The address before the run is restored by restoreDryRun.
+func (e *Executor) dryRunDeploy(instance *model.ContractInstanceSpec, address common.Address) {
+	if _, ok := e.dryRunDeployed[instance]; !ok {
+		e.dryRunDeployed[instance] = instance.Address
+	}
+	instance.Address = strings.ToLower(address.Hex())
+	instance.BoundContract().SetAddress(address)
+}
+
+// notSimulated returns the result of a call to the address, if it's the would-be address of a contract.
+func (e *Executor) notSimulated(address common.Address) *NotSimulated {
+	for instance := range e.dryRunDeployed {
+		if strings.EqualFold(instance.Address, address.Hex()) {
+			return &NotSimulated{
+				Contract: instance.ContractName(),
+				Address:  address,
+			}
+		}
+	}
+	return nil
+}
+
+// restoreDryRun restores the addresses of contract instances deployed in dry-run mode.
+func (e *Executor) restoreDryRun() {
+	for instance, address := range e.dryRunDeployed {
+		instance.Address = address
+		instance.BoundContract().SetAddress(common.HexToAddress(address))
+		delete(e.dryRunDeployed, instance)
+	}
+}
+
+// simulateTx runs the transaction through eth_call and eth_estimateGas, a nil recipient
+// stands for contract creation. A reverted transaction yields the decoded revert error.
+func (e *Executor) simulateTx(ctx model.AppContext, from common.Address, fees *txFees,
+	to *common.Address, value *big.Int, data []byte) (*Simulation, error) {
+	args := callArgs{
+		From:  from,
+		To:    to,
+		Value: (*hexutil.Big)(value),
+		Data:  data,
+	}
+	var out hexutil.Bytes
+	if err := e.ethRPC.CallContext(ctx, &out, "eth_call", args, "latest"); err != nil {
+		return nil, e.explainError(ctx, args, err)
+	}
+	var gas hexutil.Uint64
+	if err := e.ethRPC.CallContext(ctx, &gas, "eth_estimateGas", args); err != nil {
+		return nil, e.explainError(ctx, args, err)
+	}
+	nonce, err := e.ethCli.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	// earlier simulated transactions would have been sent
+	nonce += e.dryRunNonces[from]
+	e.dryRunNonces[from]++
+
+	gasPrice := fees.EffectiveGasPrice()
+	sim := &Simulation{
+		From:     from,
+		To:       to,
+		Nonce:    nonce,
+		Gas:      uint64(gas),
+		GasPrice: gasPrice,
+		Fee:      new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(uint64(gas))),
+	}
+	if to == nil {
+		// the output is the runtime code
+		contractAddr := ethfw.ContractAddress(from, nonce)
+		sim.ContractAddress = &contractAddr
+	} else {
+		sim.ReturnData = out
+	}
+	return sim, nil
+}
+
+func (e *Executor) dryRunResult(ctx model.AppContext, result *CommandResult, from common.Address,
+	fees *txFees, to *common.Address, value *big.Int, data []byte) []*CommandResult {
+	sim, err := e.simulateTx(ctx, from, fees, to, value, data)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	result.Result = sim
+	return []*CommandResult{result}
+}
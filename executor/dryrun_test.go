@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunDeploy(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "deploy-token")
+	if !assert.True(ok) {
+		return
+	}
+	ctx = ctx.WithDryRun(true)
+	results, _ := exec.RunCommand(ctx, "deploy-token")
+	if !assert.Len(results, 1) || !assert.NoError(results[0].Error) {
+		return
+	}
+	sim, ok := results[0].Result.(*Simulation)
+	if assert.True(ok) && assert.NotNil(sim.ContractAddress) {
+		assert.Equal(crypto.CreateAddress(testAccount, 0), *sim.ContractAddress)
+	}
+	// the instance is not deployed after the run
+	instance := exec.root.Contracts["Token"].Instances[0]
+	assert.False(instance.IsDeployed())
+	assert.Equal(0, node.Sent())
+	_, ok = exec.root.DeploymentState().Instance("Token", "", 0)
+	assert.False(ok)
+}
+
+// testDryRunSpec deploys the Token contract, then calls it.
+const testDryRunSpec = `
+INVENTORY:
+  genesis:
+    - $URL
+WALLETS:
+  alice:
+    privkey: $PRIVKEY
+CONTRACTS:
+  Token:
+    name: Token
+    abi: Token.abi
+    bin: Token.bin
+    instances:
+      - contract: Token
+VIEW:
+  total-supply:
+    instance:
+      contract: Token
+    method: totalSupply
+WRITE:
+  deploy-token:
+    wallet: alice
+    instance:
+      contract: Token
+  mint:
+    wallet: alice
+    instance:
+      contract: Token
+    method: mint
+TARGETS:
+  deploy-and-mint:
+    - deploy-token
+    - mint
+    - total-supply
+`
+
+func TestDryRunDependentSteps(t *testing.T) {
+	assert := assert.New(t)
+
+	_, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDryRunSpec, "deploy-and-mint")
+	if !assert.True(ok) {
+		return
+	}
+	ctx = ctx.WithDryRun(true)
+	resultsC := make(chan []*CommandResult, 10)
+	summary, found := exec.RunTarget(ctx, "deploy-and-mint", resultsC)
+	assert.True(found)
+	assert.False(summary.HasFailed())
+	var results []*CommandResult
+	for stepResults := range resultsC {
+		results = append(results, stepResults...)
+	}
+	if !assert.Len(results, 3) {
+		return
+	}
+	address := crypto.CreateAddress(testAccount, 0)
+	sim, ok := results[0].Result.(*Simulation)
+	if assert.True(ok) && assert.NotNil(sim.ContractAddress) {
+		assert.Equal(address, *sim.ContractAddress)
+	}
+	// there is no code at the would-be address to call
+	for _, result := range results[1:] {
+		assert.NoError(result.Error)
+		assert.Equal(&NotSimulated{Contract: "Token", Address: address}, result.Result)
+	}
+	assert.False(exec.root.Contracts["Token"].Instances[0].IsDeployed())
+}
@@ -0,0 +1,202 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// Event is a contract log decoded against the ABI of one of the known contracts.
+type Event struct {
+	Name     string
+	Contract string
+	// Instance is the offset of the emitting instance in the contract spec,
+	// -1 if the emitting address is not one of the known instances.
+	Instance int
+	Address  common.Address
+	TxHash   common.Hash
+	Block    uint64
+	LogIndex uint
+	Args     []*EventArg
+}
+
+// EventArg is a decoded event argument. Indexed args of dynamic types
+// are only known by their hash, which is used as the value.
+type EventArg struct {
+	Name    string
+	Type    string
+	Indexed bool
+	Value   interface{}
+}
+
+// Signature returns the event name with the names of its args, e.g. Transfer(from, to, value).
+func (ev *Event) Signature() string {
+	names := make([]string, len(ev.Args))
+	for i, arg := range ev.Args {
+		names[i] = arg.Name
+	}
+	return fmt.Sprintf("%s(%s)", ev.Name, strings.Join(names, ", "))
+}
+
+type contractEvent struct {
+	contract string
+	event    abi.Event
+}
+
+// collectEvents gathers non-anonymous events from ABIs of all known contracts,
+// indexed by their topic IDs. Different contracts may declare events with the same ID.
+func collectEvents(contracts model.Contracts) map[common.Hash][]*contractEvent {
+	events := make(map[common.Hash][]*contractEvent)
+	for name, contract := range contracts {
+		src := contract.Source()
+		if src == nil {
+			continue
+		}
+		contractABI, err := abi.JSON(bytes.NewReader(src.ABI))
+		if err != nil {
+			log.WithError(err).WithField("contract", name).Warningln("failed to parse events from ABI")
+			continue
+		}
+		for _, event := range contractABI.Events {
+			if event.Anonymous {
+				continue
+			}
+			id := event.Id()
+			events[id] = append(events[id], &contractEvent{
+				contract: name,
+				event:    event,
+			})
+		}
+	}
+	return events
+}
+
+// decodeEvents decodes logs emitted by known contracts, logs that don't match
+// any known event are skipped.
+func (e *Executor) decodeEvents(logs []*types.Log) []*Event {
+	var events []*Event
+	for _, l := range logs {
+		if ev, ok := e.decodeEvent(l); ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+func (e *Executor) decodeEvent(l *types.Log) (*Event, bool) {
+	if len(l.Topics) == 0 {
+		return nil, false
+	}
+	candidates := e.events[l.Topics[0]]
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	contractName, instanceOffset := e.findInstance(l.Address)
+	// prefer the ABI of the emitting contract, then any other ABI that fits,
+	// e.g. ERC20 and ERC721 Transfer events share the ID but not the indexed args.
+	ordered := make([]*contractEvent, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.contract == contractName {
+			ordered = append([]*contractEvent{candidate}, ordered...)
+		} else {
+			ordered = append(ordered, candidate)
+		}
+	}
+	for _, candidate := range ordered {
+		args, err := decodeEventArgs(candidate.event, l)
+		if err != nil {
+			continue
+		}
+		ev := &Event{
+			Name:     candidate.event.Name,
+			Contract: candidate.contract,
+			Instance: -1,
+			Address:  l.Address,
+			TxHash:   l.TxHash,
+			Block:    l.BlockNumber,
+			LogIndex: l.Index,
+			Args:     args,
+		}
+		if candidate.contract == contractName {
+			ev.Instance = instanceOffset
+		}
+		return ev, true
+	}
+	return nil, false
+}
+
+// findInstance returns the name of contract and the offset of its instance
+// deployed at the address.
+func (e *Executor) findInstance(address common.Address) (string, int) {
+	for name, contract := range e.root.Contracts {
+		for offset, instance := range contract.Instances {
+			if instance.IsDeployed() && common.HexToAddress(instance.Address) == address {
+				return name, offset
+			}
+		}
+	}
+	return "", -1
+}
+
+func decodeEventArgs(event abi.Event, l *types.Log) ([]*EventArg, error) {
+	var indexedCount int
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexedCount++
+		}
+	}
+	if indexedCount != len(l.Topics)-1 {
+		err := fmt.Errorf("expected %d indexed args, got %d topics", indexedCount, len(l.Topics)-1)
+		return nil, err
+	}
+	values, err := event.Inputs.NonIndexed().UnpackValues(l.Data)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]*EventArg, 0, len(event.Inputs))
+	topics := l.Topics[1:]
+	for i, input := range event.Inputs {
+		arg := &EventArg{
+			Name:    input.Name,
+			Type:    input.Type.String(),
+			Indexed: input.Indexed,
+		}
+		if len(arg.Name) == 0 {
+			arg.Name = fmt.Sprintf("arg%d", i)
+		}
+		if input.Indexed {
+			topic := topics[0]
+			topics = topics[1:]
+			arg.Value, err = decodeTopic(input.Type, topic)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			arg.Value = values[0]
+			values = values[1:]
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// decodeTopic decodes an indexed arg, values of dynamic types
+// and composite types are stored as hashes, so they cannot be decoded.
+func decodeTopic(typ abi.Type, topic common.Hash) (interface{}, error) {
+	switch typ.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return topic, nil
+	}
+	values, err := abi.Arguments{{Type: typ}}.UnpackValues(topic.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
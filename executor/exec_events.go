@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+func (e *Executor) runEventCmd(ctx model.AppContext, cmdSpec *model.EventCmdSpec) []*CommandResult {
+	result := &CommandResult{}
+	if !cmdSpec.Instance.IsDeployed() {
+		result.Error = errors.New("contract instance is not deployed yet")
+		return []*CommandResult{result}
+	}
+	event := cmdSpec.Instance.BoundContract().ABI().Events[cmdSpec.Event]
+	query, err := e.eventQuery(ctx, cmdSpec, event)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	fromBlock, toBlock := cmdSpec.BlockRange()
+	from, err := e.resolveBlockNumber(ctx, fromBlock)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	to, err := e.resolveBlockNumber(ctx, toBlock)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	logs, err := e.filterLogsChunked(ctx, query, from, to, cmdSpec.ChunkSize)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	result.Events = make([]*Event, 0, len(logs))
+	for i := range logs {
+		if ev, ok := instanceEvent(cmdSpec.Instance, event, &logs[i]); ok {
+			result.Events = append(result.Events, ev)
+		}
+	}
+	return []*CommandResult{result}
+}
+
+// instanceEvent decodes a log emitted by the instance, logs that don't match the event are skipped.
+func instanceEvent(instance *model.ContractInstanceSpec, event abi.Event, l *types.Log) (*Event, bool) {
+	args, err := decodeEventArgs(event, l)
+	if err != nil {
+		log.WithError(err).WithField("tx", l.TxHash.Hex()).Warningln("failed to decode event")
+		return nil, false
+	}
+	ev := &Event{
+		Name:     event.Name,
+		Contract: instance.ContractName(),
+		Instance: instance.Offset(),
+		Address:  l.Address,
+		TxHash:   l.TxHash,
+		Block:    l.BlockNumber,
+		LogIndex: l.Index,
+		Args:     args,
+	}
+	return ev, true
+}
+
+// eventQuery builds a filter query for the event emitted by the command's instance,
+// filter values of indexed args are converted into topics.
+func (e *Executor) eventQuery(ctx model.AppContext,
+	cmdSpec *model.EventCmdSpec, event abi.Event) (ethereum.FilterQuery, error) {
+	filterValues := cmdSpec.FilterValues()
+	topics := [][]common.Hash{{event.Id()}}
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			continue
+		}
+		values, ok := filterValues[input.Name]
+		if !ok {
+			// matches any
+			topics = append(topics, nil)
+			continue
+		}
+		values = replaceReferences(ctx, values, e.root)
+		if values == nil && len(filterValues[input.Name]) > 0 {
+			err := errors.New("insufficient arguments provided")
+			return ethereum.FilterQuery{}, err
+		}
+		argTopics := make([]common.Hash, 0, len(values))
+		for _, value := range values {
+			topic, err := eventTopic(input.Type, value)
+			if err != nil {
+				err = fmt.Errorf("filter %s: %v", input.Name, err)
+				return ethereum.FilterQuery{}, err
+			}
+			argTopics = append(argTopics, topic)
+		}
+		topics = append(topics, argTopics)
+	}
+	// trailing wildcards are implied
+	for len(topics) > 1 && topics[len(topics)-1] == nil {
+		topics = topics[:len(topics)-1]
+	}
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{common.HexToAddress(cmdSpec.Instance.Address)},
+		Topics:    topics,
+	}
+	return query, nil
+}
+
+// eventTopic encodes the value of an indexed arg, values of dynamic types are hashed.
+func eventTopic(typ abi.Type, value interface{}) (common.Hash, error) {
+	switch typ.T {
+	case abi.StringTy:
+		return crypto.Keccak256Hash([]byte(fmt.Sprintf("%v", value))), nil
+	case abi.BytesTy:
+		data, ok := value.([]byte)
+		if !ok {
+			err := fmt.Errorf("expected bytes, got %T", value)
+			return common.Hash{}, err
+		}
+		return crypto.Keccak256Hash(data), nil
+	}
+	v, err := abiValue(typ, value)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	packed, err := abi.Arguments{{Type: typ}}.Pack(v.Interface())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(packed), nil
+}
+
+func (e *Executor) resolveBlockNumber(ctx model.AppContext, block model.BlockNumber) (uint64, error) {
+	if block != model.BlockLatest {
+		return uint64(block), nil
+	}
+	var latest hexutil.Uint64
+	if err := e.ethRPC.CallContext(ctx, &latest, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(latest), nil
+}
+
+// filterLogsChunked queries logs in chunks of blocks, so large ranges don't hit the limits
+// of the node. A chunk is split in halves if the node refuses to return its logs.
+func (e *Executor) filterLogsChunked(ctx model.AppContext,
+	query ethereum.FilterQuery, from, to, chunkSize uint64) ([]types.Log, error) {
+	var logs []types.Log
+	for start := from; start <= to; {
+		end := start + chunkSize - 1
+		if end > to || end < start {
+			end = to
+		}
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+		chunk, err := e.ethCli.FilterLogs(ctx, query)
+		if err != nil {
+			if end > start {
+				chunkSize = (end - start + 1) / 2
+				log.WithError(err).WithFields(log.Fields{
+					"from":  start,
+					"to":    end,
+					"chunk": chunkSize,
+				}).Debugln("failed to get logs, retrying with a smaller chunk")
+				continue
+			}
+			return nil, err
+		}
+		logs = append(logs, chunk...)
+		if end == to {
+			break
+		}
+		start = end + 1
+	}
+	return logs, nil
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// runTargetGraph runs each step of the target as soon as the steps it needs are done, so independent steps
+// run concurrently. Commands are submitted one at a time, thus transactions of a wallet are sent in order and get
+// sequential nonces, while the transactions are awaited concurrently. Steps of nested targets are submitted
+// the same way, interleaved with the other steps. Once a step fails, no more steps are started,
+// such steps are added to the summary as not run.
+func (e *Executor) runTargetGraph(ctx model.AppContext, targetName string, target model.TargetSpec,
+	steps *targetResults, summary *TargetSummary, out chan<- []*CommandResult) bool {
+	done := make(map[string]chan struct{}, len(target))
+	for _, targetCmd := range target {
+		done[targetCmd.Name()] = make(chan struct{})
+	}
+	stopC := make(chan struct{})
+	stopOnce := new(sync.Once)
+	wg := new(sync.WaitGroup)
+	for _, targetCmd := range target {
+		wg.Add(1)
+		go func(targetCmd model.TargetCommandSpec) {
+			defer wg.Done()
+			cmdName := targetCmd.Name()
+			defer close(done[cmdName])
+			notRun := func() {
+				summary.add(&StepStatus{
+					Step:   cmdName,
+					NotRun: true,
+				})
+			}
+			for _, need := range targetCmd.Needs {
+				select {
+				case <-done[need]:
+				case <-stopC:
+					notRun()
+					return
+				case <-ctx.Done():
+					notRun()
+					return
+				}
+			}
+			select {
+			case <-stopC:
+				// a needed step has failed
+				notRun()
+				return
+			default:
+			}
+			log.WithFields(log.Fields{
+				"target":  targetName,
+				"command": cmdName,
+			}).Debugln("running target step")
+			var results []*CommandResult
+			var ok bool
+			if targetCmd.IsTarget(e.root) {
+				results, _, ok = e.runStep(ctx, targetName, targetCmd, summary, func() ([]*CommandResult, bool) {
+					return e.runNestedTarget(ctx, targetName, targetCmd, summary, out)
+				})
+				steps.Add(cmdName, results)
+			} else {
+				// retries wait for the backoff without blocking the other steps
+				results, _, ok = e.runStep(ctx, targetName, targetCmd, summary, func() ([]*CommandResult, bool) {
+					results, ok := e.submitStep(ctx, targetName, targetCmd)
+					if _, isWrite := e.root.WriteCmds[cmdName]; ok && isWrite && !isSkipped(results) {
+						ok = e.awaitStep(ctx, targetName, targetCmd, results)
+					}
+					return results, ok
+				})
+				steps.Add(cmdName, results)
+				out <- setName(results, cmdName)
+			}
+			if !ok {
+				stopOnce.Do(func() {
+					close(stopC)
+				})
+			}
+		}(targetCmd)
+	}
+	wg.Wait()
+	select {
+	case <-stopC:
+		return false
+	default:
+		return ctx.Err() == nil
+	}
+}
@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// submitLoop runs the command of a loop step once per iteration, with the args bound by the iteration.
+// The results are labeled by the iterations, e.g. send-wei[alice]. Returns false if the target execution must be stopped.
+func (e *Executor) submitLoop(ctx model.AppContext, targetName string,
+	targetCmd model.TargetCommandSpec) ([]*CommandResult, bool) {
+	cmdName := targetCmd.Name()
+	stepLog := log.WithFields(log.Fields{
+		"target":  targetName,
+		"command": cmdName,
+	})
+	iterations, err := targetCmd.Iterations(e.root)
+	if err != nil {
+		stepLog.WithError(err).Errorln("stopping target execution — failed to expand the loop")
+		return []*CommandResult{{Error: err}}, false
+	}
+	results := make([]*CommandResult, 0, len(iterations))
+	for _, iter := range iterations {
+		iterCtx := iter.Context(ctx)
+		name := fmt.Sprintf("%s[%s]", cmdName, iter.Label)
+		iterResults, ok := e.submitCmd(iterCtx, targetName, targetCmd)
+		results = append(results, setName(iterResults, name)...)
+		if !ok {
+			return results, false
+		}
+	}
+	return results, true
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// runNestedTarget runs the target invoked by a step, its results are sent out as they come, labeled by the step,
+// e.g. deploy-core/deploy-token, as well as the statuses of its steps in the summary. Returns the results
+// of the step itself, if it has been skipped or failed, and false if the target execution must be stopped.
+func (e *Executor) runNestedTarget(ctx model.AppContext, targetName string, targetCmd model.TargetCommandSpec,
+	summary *TargetSummary, out chan<- []*CommandResult) ([]*CommandResult, bool) {
+	nestedName := targetCmd.Name()
+	stepLog := log.WithFields(log.Fields{
+		"target":  targetName,
+		"command": nestedName,
+	})
+	if ok, err := e.stepCondition(ctx, targetCmd); err != nil {
+		stepLog.WithError(err).Errorln("stopping target execution — failed to evaluate the condition")
+		results := []*CommandResult{{Name: nestedName, Error: err}}
+		out <- results
+		return results, false
+	} else if !ok {
+		stepLog.WithField("when", string(targetCmd.When)).Infoln("step skipped, the condition is false")
+		results := []*CommandResult{{Name: nestedName, Result: &Skipped{Condition: string(targetCmd.When)}}}
+		out <- results
+		return results, true
+	}
+	nestedCtx, err := targetCmd.NestedContext(ctx, e.root)
+	if err != nil {
+		stepLog.WithError(err).Errorln("stopping target execution — failed to resolve args of the target")
+		results := []*CommandResult{{Name: nestedName, Error: err}}
+		out <- results
+		return results, false
+	}
+	// params of the commands get the values of the args passed to the target, as each step is run
+	nested := e.root.Targets[nestedName]
+	nestedOut := make(chan []*CommandResult, 100)
+	nestedSummary := newTargetSummary()
+	okC := make(chan bool, 1)
+	go func() {
+		defer close(nestedOut)
+		okC <- e.runTargetSteps(nestedCtx, nestedName, nested, nestedSummary, nestedOut)
+	}()
+	for nestedResults := range nestedOut {
+		for _, result := range nestedResults {
+			result.Name = nestedName + "/" + result.Name
+		}
+		out <- nestedResults
+	}
+	ok := <-okC
+	// failures within the target are ignored along with the step
+	summary.addNested(nestedName, nestedSummary, targetCmd.IgnoreErrors)
+	if !ok {
+		stepLog.Errorln("stopping target execution — nested target failed")
+		err := errors.New("target execution has been stopped")
+		return []*CommandResult{{Name: nestedName, Error: err}}, false
+	} else if nestedSummary.HasFailed() {
+		err := errors.New("steps of the target have failed")
+		return []*CommandResult{{Name: nestedName, Error: err}}, true
+	}
+	return nil, true
+}
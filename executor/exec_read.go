@@ -2,8 +2,10 @@ package executor
 
 import (
 	"errors"
+	"reflect"
 	"strings"
 
+	"github.com/AtlantPlatform/ethfw"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 
@@ -16,6 +18,11 @@ func (e *Executor) runViewCmd(ctx model.AppContext, cmdSpec *model.ViewCmdSpec)
 			Error: errors.New("contract instance is not deployed yet"),
 		}}
 	}
+	if ctx.DryRun() {
+		if notSimulated := e.notSimulated(common.HexToAddress(cmdSpec.Instance.Address)); notSimulated != nil {
+			return []*CommandResult{{Result: notSimulated}}
+		}
+	}
 	binding := cmdSpec.Instance.BoundContract()
 	binding.SetClient(e.ethCli)
 	binding.SetAddress(common.HexToAddress(cmdSpec.Instance.Address))
@@ -29,17 +36,23 @@ func (e *Executor) runViewCmd(ctx model.AppContext, cmdSpec *model.ViewCmdSpec)
 			result := &CommandResult{
 				Wallet: walletSpec.Address,
 			}
+			params, err := abiParams(binding.ABI(), cmdSpec.Method, params)
+			if err != nil {
+				result.Error = err
+				results[offset] = result
+				continue
+			}
 			opts := &bind.CallOpts{
 				From:    walletAddress,
 				Context: ctx,
 			}
-			if err := binding.Call(opts, &result.Result, cmdSpec.Method, params...); err != nil {
+			if err := callView(binding, opts, &result.Result, cmdSpec.Method, params); err != nil {
 				if strings.HasPrefix(err.Error(), "abi: cannot unmarshal tuple") {
 					storage := newValStorage()
 					result.Error = binding.Call(opts, &storage.pointers, cmdSpec.Method, params...)
 					result.Result = storage.Trim()
 				} else {
-					result.Error = err
+					result.Error = e.viewCallError(ctx, cmdSpec, walletAddress, params, err)
 				}
 			}
 			results[offset] = result
@@ -48,22 +61,60 @@ func (e *Executor) runViewCmd(ctx model.AppContext, cmdSpec *model.ViewCmdSpec)
 	}
 	result := &CommandResult{}
 	params := replaceReferences(ctx, cmdSpec.ParamValues(), e.root)
+	params, err := abiParams(binding.ABI(), cmdSpec.Method, params)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
 	opts := &bind.CallOpts{
 		Context: ctx,
 	}
-	if err := binding.Call(opts, &result.Result, cmdSpec.Method, params...); err != nil {
+	if err := callView(binding, opts, &result.Result, cmdSpec.Method, params); err != nil {
 		if strings.HasPrefix(err.Error(), "abi: cannot unmarshal tuple") {
 			storage := newValStorage()
 			result.Error = binding.Call(opts, &storage.pointers, cmdSpec.Method, params...)
 			result.Result = storage.Trim()
 		} else {
-			result.Error = err
+			result.Error = e.viewCallError(ctx, cmdSpec, common.Address{}, params, err)
 		}
 	}
 	results = append(results, result)
 	return results
 }
 
+// callView calls the view method, a single return value is unpacked into a value
+// of its own type, since the ABI unpacker cannot set an empty interface.
+func callView(binding *ethfw.BoundContract, opts *bind.CallOpts,
+	result *interface{}, method string, params []interface{}) error {
+	m, ok := binding.ABI().Methods[method]
+	if !ok || len(m.Outputs) != 1 {
+		return binding.Call(opts, result, method, params...)
+	}
+	out := reflect.New(m.Outputs[0].Type.Type)
+	if err := binding.Call(opts, out.Interface(), method, params...); err != nil {
+		return err
+	}
+	*result = out.Elem().Interface()
+	return nil
+}
+
+// viewCallError replays the failed view call to decode its revert reason,
+// the original error is kept if the call hasn't been reverted.
+func (e *Executor) viewCallError(ctx model.AppContext, cmdSpec *model.ViewCmdSpec,
+	from common.Address, params []interface{}, err error) error {
+	input, packErr := cmdSpec.Instance.BoundContract().ABI().Pack(cmdSpec.Method, params...)
+	if packErr != nil {
+		return err
+	}
+	to := common.HexToAddress(cmdSpec.Instance.Address)
+	args := callArgs{
+		From: from,
+		To:   &to,
+		Data: input,
+	}
+	return e.explainError(ctx, args, err)
+}
+
 type valStorage struct {
 	backing  [maxReturnValues]interface{}
 	pointers [maxReturnValues]*interface{}
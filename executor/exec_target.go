@@ -14,45 +14,295 @@ import (
 )
 
 func (e *Executor) runTarget(ctx model.AppContext,
-	targetName string, target model.TargetSpec, out chan<- []*CommandResult) {
+	targetName string, target model.TargetSpec, out chan<- []*CommandResult) *TargetSummary {
 
 	defer close(out)
+	if ctx.DryRun() {
+		defer e.restoreDryRun()
+	}
+
+	summary := newTargetSummary()
+	if !e.runTargetSteps(ctx, targetName, target, summary, out) {
+		summary.stop()
+	}
+	return summary
+}
 
+// runTargetSteps runs the steps of the target, the results are sent out as the steps are done.
+// Returns false if the target execution has been stopped.
+func (e *Executor) runTargetSteps(ctx model.AppContext, targetName string, target model.TargetSpec,
+	summary *TargetSummary, out chan<- []*CommandResult) bool {
+	// later steps may reference results of the earlier ones
+	steps := newTargetResults(e.root)
+	ctx = ctx.WithStepResults(steps)
+	if target.IsGraph() {
+		return e.runTargetGraph(ctx, targetName, target, steps, summary, out)
+	}
+	// transactions of deferred steps, awaited at the await steps and in the end
+	var deferred []*deferredTx
 	for _, targetCmd := range target {
 		cmdName := targetCmd.Name()
-		if cmdSpec, ok := e.root.CallCmds[cmdName]; ok {
-			results := e.runCallCmd(ctx, cmdSpec)
-			out <- setName(results, cmdName)
-		} else if cmdSpec, ok := e.root.ViewCmds[cmdName]; ok {
-			results := e.runViewCmd(ctx, cmdSpec)
-			out <- setName(results, cmdName)
-		} else if cmdSpec, ok := e.root.WriteCmds[cmdName]; ok {
-			execLog := log.WithFields(log.Fields{
-				"target":  targetName,
-				"command": cmdName,
+		if targetCmd.IsAwait() {
+			if !e.awaitDeferred(ctx, deferred, summary, out) {
+				log.WithField("target", targetName).Errorln("stopping target execution — deferred tx failed")
+				return false
+			}
+			deferred = nil
+			continue
+		}
+		if targetCmd.IsTarget(e.root) {
+			results, _, ok := e.runStep(ctx, targetName, targetCmd, summary, func() ([]*CommandResult, bool) {
+				return e.runNestedTarget(ctx, targetName, targetCmd, summary, out)
 			})
-			results := e.runWriteCmd(ctx, cmdSpec)
-			out <- setName(results, cmdName)
-			if len(results) == 0 || results[0].Error != nil {
-				execLog.Errorln("stopping target execution — tx sumbit failed")
-				return
+			steps.Add(cmdName, results)
+			if !ok {
+				return false
+			}
+			continue
+		}
+		_, isWrite := e.root.WriteCmds[cmdName]
+		results, status, ok := e.runStep(ctx, targetName, targetCmd, summary, func() ([]*CommandResult, bool) {
+			results, ok := e.submitStep(ctx, targetName, targetCmd)
+			if ok && isWrite && !isSkipped(results) && !targetCmd.IsDeferred() {
+				ok = e.awaitStep(ctx, targetName, targetCmd, results)
 			}
-			if !targetCmd.IsDeferred() {
-				awaitTimeout, _ := e.root.Config.AwaitTimeoutDuration()
-				execLog.WithFields(log.Fields{
-					// "handle":  results[0].Result,
-					"timeout": awaitTimeout.String(),
-				}).Debugln("awaiting write command transaction")
-				awaitCtx, cancelFn := context.WithTimeout(ctx, awaitTimeout)
-				if err := e.awaitTx(awaitCtx, results[0].Result); err != nil {
-					execLog.WithError(err).Errorln("stopping target execution after await")
-					cancelFn()
-					return
+			return results, ok
+		})
+		if isWrite && targetCmd.IsDeferred() && !ctx.DryRun() && !ctx.IsSignOnly() {
+			for _, result := range results {
+				if _, skipped := result.Result.(*Skipped); skipped || result.Error != nil {
+					continue
 				}
-				cancelFn()
+				deferred = append(deferred, &deferredTx{
+					Sent:          result,
+					Status:        status,
+					Confirmations: targetCmd.ConfirmationsInt(e.root.Config),
+					Timeout:       targetCmd.AwaitTimeoutDuration(e.root.Config),
+					IgnoreErrors:  targetCmd.IgnoreErrors,
+				})
 			}
 		}
+		steps.Add(cmdName, results)
+		out <- setName(results, cmdName)
+		if !ok {
+			return false
+		}
+	}
+	return e.awaitDeferred(ctx, deferred, summary, out)
+}
+
+// submitStep runs the command of a target step, transactions of WRITE commands are sent but not awaited.
+// The step is skipped if its condition is false. Returns false if the target execution must be stopped.
+// Steps are submitted one at a time, also by the concurrent steps of targets and nested targets.
+func (e *Executor) submitStep(ctx model.AppContext, targetName string,
+	targetCmd model.TargetCommandSpec) ([]*CommandResult, bool) {
+	e.submitMux.Lock()
+	defer e.submitMux.Unlock()
+	if targetCmd.IsLoop() {
+		return e.submitLoop(ctx, targetName, targetCmd)
+	}
+	return e.submitCmd(ctx, targetName, targetCmd)
+}
+
+func (e *Executor) submitCmd(ctx model.AppContext, targetName string,
+	targetCmd model.TargetCommandSpec) ([]*CommandResult, bool) {
+	cmdName := targetCmd.Name()
+	stepLog := log.WithFields(log.Fields{
+		"target":  targetName,
+		"command": cmdName,
+	})
+	if ok, err := e.stepCondition(ctx, targetCmd); err != nil {
+		stepLog.WithError(err).Errorln("stopping target execution — failed to evaluate the condition")
+		return []*CommandResult{{Error: err}}, false
+	} else if !ok {
+		stepLog.WithField("when", string(targetCmd.When)).Infoln("step skipped, the condition is false")
+		return []*CommandResult{{Result: &Skipped{Condition: string(targetCmd.When)}}}, true
+	}
+	// params get the values of the step args, e.g. bound by a loop or passed to a nested target
+	bound, ok := e.root.BindCommand(ctx, cmdName)
+	if !ok {
+		err := errors.New("command validation failed with the args of the step")
+		stepLog.WithError(err).Errorln("stopping target execution")
+		return []*CommandResult{{Error: err}}, false
+	}
+	switch cmdSpec := bound.(type) {
+	case *model.CallCmdSpec:
+		return e.runCallCmd(ctx, cmdSpec), true
+	case *model.ViewCmdSpec:
+		return e.runViewCmd(ctx, cmdSpec), true
+	case *model.EventCmdSpec:
+		return e.runEventCmd(ctx, cmdSpec), true
+	case *model.WriteCmdSpec:
+		results := e.runWriteCmd(ctx, cmdSpec)
+		if len(results) == 0 || results[0].Error != nil {
+			stepLog.Errorln("stopping target execution — tx sumbit failed")
+			return results, false
+		}
+		return results, true
 	}
+	return nil, true
+}
+
+// awaitStep awaits the transactions sent by the WRITE command of a target step (one per iteration of a loop),
+// the results are updated from their receipts. Returns false if the target execution must be stopped.
+func (e *Executor) awaitStep(ctx model.AppContext, targetName string,
+	targetCmd model.TargetCommandSpec, results []*CommandResult) bool {
+	if ctx.DryRun() || ctx.IsSignOnly() {
+		return true
+	}
+	execLog := log.WithFields(log.Fields{
+		"target":  targetName,
+		"command": targetCmd.Name(),
+	})
+	sent := make([]*CommandResult, 0, len(results))
+	values := make([]interface{}, 0, len(results))
+	confirmations := make([]uint64, 0, len(results))
+	for _, result := range results {
+		if _, skipped := result.Result.(*Skipped); skipped {
+			continue
+		}
+		sent = append(sent, result)
+		values = append(values, result.Result)
+		confirmations = append(confirmations, targetCmd.ConfirmationsInt(e.root.Config))
+	}
+	awaitTimeout := targetCmd.AwaitTimeoutDuration(e.root.Config)
+	execLog.WithFields(log.Fields{
+		// "handle":  results[0].Result,
+		"timeout": awaitTimeout.String(),
+	}).Debugln("awaiting write command transaction")
+	awaitCtx, cancelFn := ctx.WithTimeout(awaitTimeout)
+	receipts, errs := e.awaitTxs(awaitCtx, values, confirmations)
+	cancelFn()
+	ok := true
+	for i, result := range sent {
+		resultLog := execLog
+		if len(result.Name) > 0 {
+			resultLog = execLog.WithField("iteration", result.Name)
+		}
+		receipt, err := receipts[i], errs[i]
+		if receipt != nil {
+			result.Gas = e.gasUsage(ctx, receipt)
+		} else {
+			err = awaitFailure(awaitCtx, awaitTimeout, err)
+		}
+		deployment, isDeployment := result.Result.(*Deployment)
+		if err != nil {
+			if isDeployment && receipt != nil {
+				e.resetDeployment(deployment)
+			}
+			result.Error = err
+			resultLog.WithError(err).Errorln("stopping target execution after await")
+			ok = false
+			continue
+		}
+		if isDeployment {
+			if err := e.confirmDeployment(ctx, deployment, receipt); err != nil {
+				e.resetDeployment(deployment)
+				result.Error = err
+				resultLog.WithError(err).Errorln("stopping target execution after deployment")
+				ok = false
+				continue
+			}
+		} else {
+			// the transaction may have been replaced with bumped fees
+			result.Result = "tx:" + strings.ToLower(receipt.TxHash.Hex())
+		}
+		result.Events = e.decodeEvents(receipt.Logs)
+	}
+	return ok
+}
+
+// TxStatus is the final status of a transaction, awaited by the target.
+type TxStatus struct {
+	TxHash  common.Hash
+	Success bool
+	Block   uint64
+	GasUsed uint64
+	// ContractAddress is set if the transaction has deployed a contract
+	ContractAddress *common.Address
+	// Error explains the failure
+	Error error
+}
+
+// deferredTx is a transaction sent by a deferred step, it is awaited later.
+type deferredTx struct {
+	Sent *CommandResult
+	// Status of the step is updated if the transaction fails
+	Status        *StepStatus
+	Confirmations uint64
+	Timeout       time.Duration
+	IgnoreErrors  bool
+}
+
+// awaitDeferred waits for the transactions of deferred steps within the longest await timeout of the steps,
+// and reports the final status of each one. Returns false if any of them has failed or not been mined,
+// unless the errors of its step are ignored.
+func (e *Executor) awaitDeferred(ctx model.AppContext, deferred []*deferredTx,
+	summary *TargetSummary, out chan<- []*CommandResult) bool {
+	if len(deferred) == 0 {
+		return true
+	}
+	var awaitTimeout time.Duration
+	for _, tx := range deferred {
+		if tx.Timeout > awaitTimeout {
+			awaitTimeout = tx.Timeout
+		}
+	}
+	awaitCtx, cancelFn := ctx.WithTimeout(awaitTimeout)
+	defer cancelFn()
+	values := make([]interface{}, 0, len(deferred))
+	confirmations := make([]uint64, 0, len(deferred))
+	for _, tx := range deferred {
+		values = append(values, tx.Sent.Result)
+		confirmations = append(confirmations, tx.Confirmations)
+	}
+	// receipts of all deferred transactions are checked at once
+	receipts, errs := e.awaitTxs(awaitCtx, values, confirmations)
+	ok := true
+	for i, tx := range deferred {
+		result := &CommandResult{
+			Name:   tx.Sent.Name,
+			Wallet: tx.Sent.Wallet,
+		}
+		receipt, err := receipts[i], errs[i]
+		deployment, isDeployment := tx.Sent.Result.(*Deployment)
+		if receipt == nil {
+			err = awaitFailure(awaitCtx, awaitTimeout, err)
+			result.Error = err
+			out <- []*CommandResult{result}
+			summary.fail(tx.Status, err, tx.IgnoreErrors)
+			if !tx.IgnoreErrors {
+				ok = false
+			}
+			continue
+		}
+		if isDeployment {
+			if err == nil {
+				err = e.confirmDeployment(ctx, deployment, receipt)
+			}
+			if err != nil {
+				e.resetDeployment(deployment)
+			}
+		}
+		result.Result = &TxStatus{
+			TxHash:          receipt.TxHash,
+			Success:         err == nil,
+			Block:           uint64(receipt.BlockNumber),
+			GasUsed:         uint64(receipt.GasUsed),
+			ContractAddress: receipt.ContractAddress,
+			Error:           err,
+		}
+		result.Events = e.decodeEvents(receipt.Logs)
+		result.Gas = e.gasUsage(ctx, receipt)
+		out <- []*CommandResult{result}
+		if err != nil {
+			summary.fail(tx.Status, err, tx.IgnoreErrors)
+			if !tx.IgnoreErrors {
+				ok = false
+			}
+		}
+	}
+	return ok
 }
 
 func setName(results []*CommandResult, name string) []*CommandResult {
@@ -62,55 +312,38 @@ func setName(results []*CommandResult, name string) []*CommandResult {
 		}}
 	}
 	for i := range results {
-		results[i].Name = name
+		if len(results[i].Name) == 0 {
+			// results of loop iterations are labeled already
+			results[i].Name = name
+		}
 	}
 	return results
 }
 
-func (e *Executor) awaitTx(ctx context.Context, v interface{}) error {
-	value, ok := v.(string)
-	if !ok {
-		err := fmt.Errorf("unknown result type: %T", v)
-		return err
-	}
-	if strings.HasPrefix(value, "tx:") {
-		value = value[3:]
-	} else if !strings.HasPrefix(value, "0x") {
-		err := fmt.Errorf("value is not a hex-string: %s", value)
-		return err
-	}
-
-	tx, isPending, err := e.ethCli.TransactionByHash(ctx, common.HexToHash(value))
-	if err != nil {
-		return err
-	} else if !isPending {
-		return nil
-	}
-	t := time.NewTimer(time.Second)
-	defer t.Stop()
-	for {
-		select {
-		case <-t.C:
-			_, isPending, err = e.ethCli.TransactionByHash(ctx, tx.Hash())
-			if err == nil && !isPending {
-				receipt, err := e.ethCli.TransactionReceipt(ctx, tx.Hash())
-				if err != nil {
-					return err
-				} else if status := receipt.Status; status == 0 {
-					err := errors.New("transction execution ended with failing status code")
-					return err
-				}
-				// finally a transaction receipt,
-				// with a successful status
-				return nil
-			} else if err != nil {
-				log.WithError(err).Warningln("error while checking the transaction status")
-				t.Reset(10 * time.Second)
-				continue
+// checkReceipt ensures that a mined transaction has a successful status,
+// deployment state gets updated accordingly. The receipt of a failed transaction is returned along with the error.
+func (e *Executor) checkReceipt(ctx context.Context, receipt *txReceipt) (*txReceipt, error) {
+	txHash := receipt.TxHash
+	state := e.root.DeploymentState()
+	if status := receipt.Status; status == 0 {
+		if state != nil {
+			if err := state.Forget(txHash.Hex()); err != nil {
+				log.WithError(err).Warningln("failed to save deployment state")
 			}
-			t.Reset(time.Second)
-		case <-ctx.Done():
-			return ctx.Err()
+		}
+		if revertErr := e.explainTx(ctx, txHash); revertErr != nil {
+			err := fmt.Errorf("transction execution ended with failing status code: %v", revertErr)
+			return receipt, err
+		}
+		err := errors.New("transction execution ended with failing status code")
+		return receipt, err
+	}
+	if state != nil {
+		if err := state.Confirm(txHash.Hex(), uint64(receipt.BlockNumber)); err != nil {
+			log.WithError(err).Warningln("failed to save deployment state")
 		}
 	}
+	// finally a transaction receipt,
+	// with a successful status
+	return receipt, nil
 }
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"errors"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// WatchEvents streams events of an EVENTS command as they arrive, until the context is done.
+// Logs are received via subscription if the node supports it (IPC, WebSocket),
+// otherwise the node is polled for new blocks.
+func (e *Executor) WatchEvents(ctx model.AppContext, cmdName string, resultsC chan<- []*CommandResult) bool {
+	cmdSpec, ok := e.root.EventCmds[cmdName]
+	if !ok {
+		return false
+	}
+	defer close(resultsC)
+
+	if !cmdSpec.Instance.IsDeployed() {
+		resultsC <- []*CommandResult{{
+			Name:  cmdName,
+			Error: errors.New("contract instance is not deployed yet"),
+		}}
+		return true
+	}
+	event := cmdSpec.Instance.BoundContract().ABI().Events[cmdSpec.Event]
+	query, err := e.eventQuery(ctx, cmdSpec, event)
+	if err != nil {
+		resultsC <- []*CommandResult{{
+			Name:  cmdName,
+			Error: err,
+		}}
+		return true
+	}
+	watchLog := log.WithField("command", cmdName)
+	if err := e.subscribeEvents(ctx, cmdName, cmdSpec, event, query, resultsC); err == rpc.ErrNotificationsUnsupported {
+		watchLog.Debugln("subscriptions are not supported by the node, polling for logs")
+		err = e.pollEvents(ctx, cmdName, cmdSpec, event, query, resultsC)
+		if err != nil && ctx.Err() == nil {
+			resultsC <- []*CommandResult{{
+				Name:  cmdName,
+				Error: err,
+			}}
+		}
+	} else if err != nil && ctx.Err() == nil {
+		resultsC <- []*CommandResult{{
+			Name:  cmdName,
+			Error: err,
+		}}
+	}
+	return true
+}
+
+func (e *Executor) subscribeEvents(ctx model.AppContext, cmdName string, cmdSpec *model.EventCmdSpec,
+	event abi.Event, query ethereum.FilterQuery, resultsC chan<- []*CommandResult) error {
+	logsC := make(chan types.Log, 100)
+	sub, err := e.ethCli.SubscribeFilterLogs(ctx, query, logsC)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case l := <-logsC:
+			if l.Removed {
+				log.WithField("tx", l.TxHash.Hex()).Warningln("event has been removed due to chain reorganisation")
+				continue
+			}
+			if ev, ok := instanceEvent(cmdSpec.Instance, event, &l); ok {
+				resultsC <- []*CommandResult{{
+					Name:   cmdName,
+					Events: []*Event{ev},
+				}}
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *Executor) pollEvents(ctx model.AppContext, cmdName string, cmdSpec *model.EventCmdSpec,
+	event abi.Event, query ethereum.FilterQuery, resultsC chan<- []*CommandResult) error {
+	pollInterval := e.pollInterval()
+	last, err := e.resolveBlockNumber(ctx, model.BlockLatest)
+	if err != nil {
+		return err
+	}
+	t := time.NewTimer(pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			latest, err := e.resolveBlockNumber(ctx, model.BlockLatest)
+			if err != nil {
+				log.WithError(err).Warningln("error while checking the latest block")
+				t.Reset(pollInterval)
+				continue
+			} else if latest <= last {
+				t.Reset(pollInterval)
+				continue
+			}
+			logs, err := e.filterLogsChunked(ctx, query, last+1, latest, cmdSpec.ChunkSize)
+			if err != nil {
+				log.WithError(err).Warningln("error while polling for logs")
+				t.Reset(pollInterval)
+				continue
+			}
+			for i := range logs {
+				if ev, ok := instanceEvent(cmdSpec.Instance, event, &logs[i]); ok {
+					resultsC <- []*CommandResult{{
+						Name:   cmdName,
+						Events: []*Event{ev},
+					}}
+				}
+			}
+			last = latest
+			t.Reset(pollInterval)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
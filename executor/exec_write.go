@@ -3,13 +3,13 @@ package executor
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/AtlantPlatform/ethfw"
-	ethereum "github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/AtlantPlatform/ethereum-playbook/model"
@@ -23,6 +23,10 @@ func (e *Executor) runWriteCmd(ctx model.AppContext, cmdSpec *model.WriteCmdSpec
 				binding := instance.BoundContract()
 				binding.SetClient(e.ethCli)
 				binding.SetAddress(common.HexToAddress(instance.Address))
+				if ctx.IsSignOnly() {
+					// symbols are fetched from the node
+					continue
+				}
 				contractLog := log.WithFields(log.Fields{
 					"contract": name,
 					"address":  instance.Address,
@@ -44,16 +48,21 @@ func (e *Executor) runWriteCmd(ctx model.AppContext, cmdSpec *model.WriteCmdSpec
 	result := &CommandResult{}
 	wallet := cmdSpec.MatchingWallet()
 	account := common.HexToAddress(wallet.Address)
-	balance, err := e.ethCli.BalanceAt(ctx, account, nil)
-	if err != nil {
-		result.Error = err
-		return []*CommandResult{result}
-	}
-	wallet.Balance = balance
-	gasPrice, _ := e.root.Config.GasPriceInt()
-	suggestedGas, err := e.ethCli.SuggestGasPrice(ctx)
-	if err == nil && suggestedGas.Cmp(gasPrice) > 0 {
-		gasPrice = suggestedGas
+	var fees *txFees
+	var err error
+	if ctx.IsSignOnly() {
+		fees = e.signOnlyFees(cmdSpec)
+	} else {
+		balance, err := e.ethCli.BalanceAt(ctx, account, nil)
+		if err != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		}
+		wallet.Balance = balance
+		if fees, err = e.txFees(ctx, cmdSpec); err != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		}
 	}
 	var value model.ExtendedValue
 	if len(cmdSpec.Value) > 0 {
@@ -70,82 +79,124 @@ func (e *Executor) runWriteCmd(ctx model.AppContext, cmdSpec *model.WriteCmdSpec
 	if denominatorCommonOrEmpty && len(cmdSpec.To) > 0 {
 		// just send ether
 		to := common.HexToAddress(cmdSpec.To)
-		callMsg := ethereum.CallMsg{
-			From:     account,
-			To:       &to,
-			Gas:      0,
-			GasPrice: gasPrice,
-			Value:    value.Value,
-			Data:     nil,
-		}
-		nonce, err := e.ethCli.PendingNonceAt(ctx, account)
-		if err != nil {
-			result.Error = err
-			return []*CommandResult{result}
-		}
-		gasLimit, _ := e.root.Config.GasLimitInt()
-		estimatedGasLimit, err := e.ethCli.EstimateGas(ctx, callMsg)
-		if err == nil && estimatedGasLimit < gasLimit {
-			gasLimit = estimatedGasLimit
-		}
-		tx := types.NewTransaction(nonce, to, value.Value, gasLimit, gasPrice, nil)
-		pk, ok := e.keycache.PrivateKey(account, wallet.Password)
-		if !ok {
-			if pk = wallet.PrivKeyECDSA(); pk == nil {
-				result.Error = errors.New("failed to get account private key")
+		if ctx.DryRun() {
+			return e.dryRunResult(ctx, result, account, fees, &to, value.Value, nil)
+		} else if ctx.IsSignOnly() {
+			signed, err := e.signTx(ctx, cmdSpec, wallet, fees, &to, value.Value, nil)
+			if err != nil {
+				result.Error = err
 				return []*CommandResult{result}
-			} else {
-				e.keycache.SetPrivateKey(account, pk)
 			}
+			return e.signOnlyResult(result, signed)
 		}
-		chainID, _ := e.root.Config.ChainIDInt()
-		signer := types.NewEIP155Signer(chainID)
-		signedTx, err := types.SignTx(tx, signer, pk)
+		txHash, _, err := e.sendTx(ctx, wallet, fees, &to, value.Value, nil)
 		if err != nil {
-			result.Error = err
+			result.Error = e.explainError(ctx, callArgs{
+				From:  account,
+				To:    &to,
+				Value: (*hexutil.Big)(value.Value),
+			}, err)
 			return []*CommandResult{result}
 		}
-		result.Error = e.ethCli.SendTransaction(ctx, signedTx)
-		result.Result = "tx:" + strings.ToLower(signedTx.Hash().Hex())
+		result.Result = "tx:" + strings.ToLower(txHash.Hex())
 		return []*CommandResult{result}
 	}
 	if denominatorCommonOrEmpty && !cmdSpec.Instance.IsDeployed() {
 		// need to deploy an instance
+		if len(cmdSpec.Instance.BoundContract().Source().Bin) == 0 {
+			result.Error = errors.New("contract has no bytecode to deploy, only ABI is known")
+			return []*CommandResult{result}
+		}
 		params := replaceWalletPlaceholders(cmdSpec.ParamValues(), account)
 		params = replaceReferences(ctx, params, e.root)
-		if pk := wallet.PrivKeyECDSA(); pk != nil {
-			e.keycache.SetPrivateKey(account, pk)
-		}
-		opts := &bind.TransactOpts{
-			From:     account,
-			Nonce:    nil, // pending state
-			Signer:   e.keycache.SignerFn(account, wallet.Password),
-			Value:    value.Value,
-			GasPrice: gasPrice,
-			GasLimit: 0, // estimate
-			Context:  ctx,
-		}
-		contractAddr, tx, err := cmdSpec.Instance.BoundContract().DeployContract(opts, params...)
+		params, err = abiParams(cmdSpec.Instance.BoundContract().ABI(), "", params)
 		if err != nil {
 			result.Error = err
 			return []*CommandResult{result}
 		}
+		if ctx.DryRun() || ctx.IsSignOnly() {
+			data, err := deployData(cmdSpec.Instance.BoundContract(), params)
+			if err != nil {
+				result.Error = err
+				return []*CommandResult{result}
+			} else if ctx.DryRun() {
+				results := e.dryRunResult(ctx, result, account, fees, nil, value.Value, data)
+				if sim, ok := results[0].Result.(*Simulation); ok {
+					e.dryRunDeploy(cmdSpec.Instance, *sim.ContractAddress)
+				}
+				return results
+			}
+			signed, err := e.signTx(ctx, cmdSpec, wallet, fees, nil, value.Value, data)
+			if err != nil {
+				result.Error = err
+				return []*CommandResult{result}
+			}
+			signed.Contract = cmdSpec.Instance.ContractName()
+			signed.Instance = cmdSpec.Instance.Offset()
+			signed.InstanceID = cmdSpec.Instance.ID
+			// later steps use the would-be address
+			cmdSpec.Instance.Address = signed.Address
+			cmdSpec.Instance.BoundContract().SetAddress(common.HexToAddress(signed.Address))
+			return e.signOnlyResult(result, signed)
+		}
+		contractAddr, txHash, err := e.deployContract(ctx, wallet, fees, cmdSpec.Instance.BoundContract(), value.Value, params)
+		if err != nil {
+			data, packErr := deployData(cmdSpec.Instance.BoundContract(), params)
+			if packErr != nil {
+				result.Error = err
+				return []*CommandResult{result}
+			}
+			result.Error = e.explainError(ctx, callArgs{
+				From:  account,
+				Value: (*hexutil.Big)(value.Value),
+				Data:  data,
+			}, err)
+			return []*CommandResult{result}
+		}
 		cmdSpec.Instance.Address = strings.ToLower(contractAddr.Hex())
 		cmdSpec.Instance.BoundContract().SetAddress(contractAddr)
 		contractLog := log.WithFields(log.Fields{
 			"contract": cmdSpec.Instance.Name,
 			"address":  cmdSpec.Instance.Address,
 		})
+		if state := e.root.DeploymentState(); state != nil {
+			deployed := &model.DeployedInstance{
+				ID:       cmdSpec.Instance.ID,
+				Instance: cmdSpec.Instance.Offset(),
+				Address:  cmdSpec.Instance.Address,
+				TxHash:   strings.ToLower(txHash.Hex()),
+				Deployer: strings.ToLower(account.Hex()),
+			}
+			if err := state.Record(cmdSpec.Instance.ContractName(), deployed); err != nil {
+				contractLog.WithError(err).Warningln("failed to save deployment state")
+			}
+		}
+		deployment := &Deployment{
+			Contract: cmdSpec.Instance.ContractName(),
+			Address:  contractAddr,
+			TxHash:   txHash,
+
+			instance: cmdSpec.Instance,
+		}
 		if symbolName := cmdSpec.Instance.FetchTokenSymbol(ctx); len(symbolName) > 0 {
+			deployment.Symbol = symbolName
 			contractLog.WithField("symbol", strings.ToUpper(symbolName)).Println("fetched token symbol")
 		} else {
 			contractLog.Println("contract deployed")
 		}
-		result.Result = "tx:" + strings.ToLower(tx.Hash().Hex())
+		result.Result = deployment
 		return []*CommandResult{result}
 	}
 	// at this point, contract is deployed and we just want to use its method
+	if len(value.Denominator) == 0 && len(cmdSpec.Method) == 0 {
+		result.Error = fmt.Errorf("contract instance is already deployed at %s", cmdSpec.Instance.Address)
+		return []*CommandResult{result}
+	}
 	var params []interface{}
+	var contractAddr common.Address
+	if cmdSpec.Instance != nil {
+		contractAddr = common.HexToAddress(cmdSpec.Instance.Address)
+	}
 	if len(value.Denominator) > 0 {
 		instance, ok := e.root.Contracts.FindByTokenSymbol(value.Denominator)
 		if !ok {
@@ -157,6 +208,7 @@ func (e *Executor) runWriteCmd(ctx model.AppContext, cmdSpec *model.WriteCmdSpec
 		}
 		// override binding with other referenced contract
 		binding = instance.BoundContract()
+		contractAddr = common.HexToAddress(instance.Address)
 		if len(cmdSpec.To) == 0 {
 			result.Error = errors.New("no transfer recipient address specified")
 			return []*CommandResult{result}
@@ -167,23 +219,80 @@ func (e *Executor) runWriteCmd(ctx model.AppContext, cmdSpec *model.WriteCmdSpec
 	} else {
 		params = replaceWalletPlaceholders(cmdSpec.ParamValues(), account)
 		params = replaceReferences(ctx, params, e.root)
+		params, err = abiParams(binding.ABI(), cmdSpec.Method, params)
+		if err != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		}
 	}
-	if pk := wallet.PrivKeyECDSA(); pk != nil {
-		e.keycache.SetPrivateKey(account, pk)
-	}
-	opts := &bind.TransactOpts{
-		From:     account,
-		Nonce:    nil, // pending state
-		Signer:   e.keycache.SignerFn(account, wallet.Password),
-		GasPrice: gasPrice,
-		GasLimit: 0, // estimate
-		Context:  ctx,
+	if ctx.DryRun() || ctx.IsSignOnly() {
+		input, err := binding.ABI().Pack(cmdSpec.Method, params...)
+		if err != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		} else if ctx.DryRun() {
+			if notSimulated := e.notSimulated(contractAddr); notSimulated != nil {
+				result.Result = notSimulated
+				return []*CommandResult{result}
+			}
+			return e.dryRunResult(ctx, result, account, fees, &contractAddr, nil, input)
+		}
+		signed, err := e.signTx(ctx, cmdSpec, wallet, fees, &contractAddr, nil, input)
+		if err != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		}
+		return e.signOnlyResult(result, signed)
 	}
-	tx, err := binding.Transact(opts, cmdSpec.Method, params...)
+	txHash, err := e.transact(ctx, wallet, fees, binding, contractAddr, cmdSpec.Method, params)
 	if err != nil {
-		result.Error = err
+		input, packErr := binding.ABI().Pack(cmdSpec.Method, params...)
+		if packErr != nil {
+			result.Error = err
+			return []*CommandResult{result}
+		}
+		result.Error = e.explainError(ctx, callArgs{
+			From: account,
+			To:   &contractAddr,
+			Data: input,
+		}, err)
 		return []*CommandResult{result}
 	}
-	result.Result = "tx:" + strings.ToLower(tx.Hash().Hex())
+	result.Result = "tx:" + strings.ToLower(txHash.Hex())
 	return []*CommandResult{result}
 }
+
+// deployContract deploys the contract, returns the address of the contract and the transaction hash.
+func (e *Executor) deployContract(ctx model.AppContext, wallet *model.WalletSpec, fees *txFees,
+	binding *ethfw.BoundContract, value *big.Int, params []interface{}) (common.Address, common.Hash, error) {
+	data, err := deployData(binding, params)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	txHash, nonce, err := e.sendTx(ctx, wallet, fees, nil, value, data)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	account := common.HexToAddress(wallet.Address)
+	return crypto.CreateAddress(account, nonce), txHash, nil
+}
+
+// deployData returns the bytecode of the contract followed by the packed constructor params.
+func deployData(binding *ethfw.BoundContract, params []interface{}) ([]byte, error) {
+	input, err := binding.ABI().Pack("", params...)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.FromHex(binding.Source().Bin), input...), nil
+}
+
+// transact calls the contract method with a transaction.
+func (e *Executor) transact(ctx model.AppContext, wallet *model.WalletSpec, fees *txFees,
+	binding *ethfw.BoundContract, contractAddr common.Address, method string, params []interface{}) (common.Hash, error) {
+	input, err := binding.ABI().Pack(method, params...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	txHash, _, err := e.sendTx(ctx, wallet, fees, &contractAddr, nil, input)
+	return txHash, err
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"math/big"
+	"sync"
 
 	"github.com/AtlantPlatform/ethfw"
 	"github.com/ethereum/go-ethereum/common"
@@ -21,34 +22,74 @@ type Executor struct {
 	ethRPC   *rpc.Client
 	ethCli   *ethclient.Client
 	keycache ethfw.KeyCache
+
+	customErrors map[string]*customError
+	events       map[common.Hash][]*contractEvent
+
+	// next nonces of accounts, synced with the node upon first use
+	nonces       ethfw.NonceCache
+	noncesSynced map[common.Address]struct{}
+	noncesMux    sync.Mutex
+
+	// steps of targets are submitted one at a time, so transactions of a wallet get sequential nonces
+	submitMux sync.Mutex
+
+	// transactions simulated in dry-run mode, per account
+	dryRunNonces map[common.Address]uint64
+	// contract instances deployed in dry-run mode, along with their addresses before the run
+	dryRunDeployed map[*model.ContractInstanceSpec]string
+	// signed transactions, in sign-only mode
+	signed *model.SignedTxs
 }
 
 func New(ctx model.AppContext, root *model.Spec) (*Executor, error) {
 	nodeGroup := ctx.NodeGroup()
-	ethRPC, ok := root.Inventory.GetClient(nodeGroup)
-	if !ok {
-		err := errors.New("no valid RPC client found in the inventory")
-		return nil, err
-	}
 	executor := &Executor{
 		root:      root,
 		nodeGroup: nodeGroup,
-		ethRPC:    ethRPC,
-		ethCli:    ethclient.NewClient(ethRPC),
 		keycache:  ctx.KeyCache(),
+
+		customErrors: collectCustomErrors(root.Contracts),
+		events:       collectEvents(root.Contracts),
+		nonces:       ethfw.NewNonceCache(),
+		noncesSynced: make(map[common.Address]struct{}),
+		dryRunNonces: make(map[common.Address]uint64),
+
+		dryRunDeployed: make(map[*model.ContractInstanceSpec]string),
+	}
+	if ctx.IsSignOnly() {
+		// transactions are signed offline with the given nonces, no node is used
+		for name, nonce := range ctx.SignNonces() {
+			wallet, _ := root.Wallets.WalletSpec(name)
+			executor.setNonce(common.HexToAddress(wallet.Address), nonce)
+		}
+		executor.signed = model.NewSignedTxs(ctx.SignOnly())
+		return executor, nil
+	}
+	ethRPC, ok := root.Inventory.GetClient(nodeGroup)
+	if !ok {
+		err := errors.New("no valid RPC client found in the inventory")
+		return nil, err
 	}
+	executor.ethRPC = ethRPC
+	executor.ethCli = ethclient.NewClient(ethRPC)
 	return executor, nil
 }
 
-func (e *Executor) RunTarget(ctx model.AppContext, targetName string, resultsC chan<- []*CommandResult) bool {
+// RunTarget runs the target, the results of its steps are sent into resultsC. Returns the summary of the statuses
+// of the steps, and false if the target is not found.
+func (e *Executor) RunTarget(ctx model.AppContext, targetName string,
+	resultsC chan<- []*CommandResult) (*TargetSummary, bool) {
 	if target, ok := e.root.Targets[targetName]; ok {
-		e.runTarget(ctx, targetName, target, resultsC)
-		return true
+		return e.runTarget(ctx, targetName, target, resultsC), true
 	}
-	return false
+	return nil, false
 }
 
 func (e *Executor) RunCommand(ctx model.AppContext, cmdName string) ([]*CommandResult, bool) {
+	if ctx.DryRun() {
+		defer e.restoreDryRun()
+	}
 	if cmdSpec, ok := e.root.CallCmds[cmdName]; ok {
 		return e.runCallCmd(ctx, cmdSpec), true
 	}
@@ -56,7 +97,12 @@ func (e *Executor) RunCommand(ctx model.AppContext, cmdName string) ([]*CommandR
 		return e.runViewCmd(ctx, cmdSpec), true
 	}
 	if cmdSpec, ok := e.root.WriteCmds[cmdName]; ok {
-		return e.runWriteCmd(ctx, cmdSpec), true
+		results := e.runWriteCmd(ctx, cmdSpec)
+		e.awaitDeployments(ctx, results)
+		return results, true
+	}
+	if cmdSpec, ok := e.root.EventCmds[cmdName]; ok {
+		return e.runEventCmd(ctx, cmdSpec), true
 	}
 	return nil, false
 }
@@ -66,6 +112,11 @@ type CommandResult struct {
 	Wallet string
 	Result interface{}
 	Error  error
+	// Events are emitted by the mined transaction of a WRITE command,
+	// or found by an EVENTS command.
+	Events []*Event
+	// Gas is used by the mined transaction of a WRITE command, awaited within a target or for a deployment.
+	Gas *GasUsage
 }
 
 func replaceWalletPlaceholders(params []interface{}, walletAddress common.Address) []interface{} {
@@ -76,6 +127,12 @@ func replaceWalletPlaceholders(params []interface{}, walletAddress common.Addres
 				newParams[i] = walletAddress
 			}
 		}
+		if composite, ok := param.(*model.CompositeParam); ok {
+			newParams[i] = &model.CompositeParam{
+				Type:  composite.Type,
+				Elems: replaceWalletPlaceholders(composite.Elems, walletAddress),
+			}
+		}
 	}
 	return newParams
 }
@@ -102,6 +159,24 @@ func replaceReferences(ctx model.AppContext, params []interface{}, root *model.S
 			}
 			newParams[i] = ctx.AppCommandArgs()[arg.ArgID]
 		}
+		if ref, ok := param.(*model.StepReference); ok {
+			v, err := ctx.ResolveStepReference(ref)
+			if err != nil {
+				log.WithError(err).WithField("command", ctx.AppCommand()).Errorln("failed to resolve step reference")
+				return nil
+			}
+			newParams[i] = v
+		}
+		if composite, ok := param.(*model.CompositeParam); ok {
+			elems := replaceReferences(ctx, composite.Elems, root)
+			if elems == nil && len(composite.Elems) > 0 {
+				return nil
+			}
+			newParams[i] = &model.CompositeParam{
+				Type:  composite.Type,
+				Elems: elems,
+			}
+		}
 	}
 	return newParams
 }
@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/AtlantPlatform/ethfw"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// txFees are the fees of a transaction, either legacy or dynamic (EIP-1559).
+type txFees struct {
+	// upper bound of the estimated gas
+	GasLimit uint64
+	GasPrice *big.Int
+
+	BaseFee              *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// Dynamic reports whether a dynamic-fee transaction should be sent.
+func (f *txFees) Dynamic() bool {
+	return f.MaxFeePerGas != nil
+}
+
+// EffectiveGasPrice returns the price of gas paid at the current base fee.
+func (f *txFees) EffectiveGasPrice() *big.Int {
+	if !f.Dynamic() {
+		return f.GasPrice
+	}
+	price := new(big.Int).Add(f.BaseFee, f.MaxPriorityFeePerGas)
+	if price.Cmp(f.MaxFeePerGas) > 0 {
+		return f.MaxFeePerGas
+	}
+	return price
+}
+
+const (
+	feeHistoryBlocks     = 10
+	feeHistoryPercentile = 50
+)
+
+// defaultPriorityFee is used if the node cannot suggest a priority fee.
+var defaultPriorityFee = ethfw.Gwei(1).ToInt()
+
+// txFees resolves the fees for the transaction of the command. Dynamic fees are used if the node
+// reports a base fee, unset fees are estimated from the fee history. Otherwise the legacy gas price is used.
+func (e *Executor) txFees(ctx model.AppContext, cmdSpec *model.WriteCmdSpec) (*txFees, error) {
+	feesLog := log.WithField("command", ctx.AppCommand())
+	maxFee := cmdSpec.MaxFeePerGasInt(e.root.Config)
+	maxPriorityFee := cmdSpec.MaxPriorityFeePerGasInt(e.root.Config)
+	header, err := e.latestHeader(ctx)
+	if err != nil {
+		return nil, err
+	} else if header.BaseFee == nil {
+		if maxFee != nil || maxPriorityFee != nil {
+			feesLog.Warningln("node reports no base fee, falling back to legacy gas price")
+		}
+		gasPrice, _ := e.root.Config.GasPriceInt()
+		suggestedGas, err := e.ethCli.SuggestGasPrice(ctx)
+		if err == nil && suggestedGas.Cmp(gasPrice) > 0 {
+			gasPrice = suggestedGas
+		}
+		return &txFees{
+			GasLimit: cmdSpec.GasLimitInt(e.root.Config),
+			GasPrice: gasPrice,
+		}, nil
+	}
+	baseFee := header.BaseFee.ToInt()
+	if maxPriorityFee == nil {
+		maxPriorityFee = e.suggestPriorityFee(ctx)
+	}
+	if maxFee == nil {
+		// stays valid for a few blocks of growing base fee
+		maxFee = new(big.Int).Mul(baseFee, big.NewInt(2))
+		maxFee.Add(maxFee, maxPriorityFee)
+	}
+	if maxFee.Cmp(maxPriorityFee) < 0 {
+		err := fmt.Errorf("max priority fee %s is greater than max fee %s", maxPriorityFee, maxFee)
+		return nil, err
+	} else if maxFee.Cmp(baseFee) < 0 {
+		feesLog.WithFields(log.Fields{
+			"maxFeePerGas": maxFee.String(),
+			"baseFee":      baseFee.String(),
+		}).Warningln("max fee is below the current base fee, transaction may stay pending")
+	}
+	feesLog.WithFields(log.Fields{
+		"baseFee":              baseFee.String(),
+		"maxFeePerGas":         maxFee.String(),
+		"maxPriorityFeePerGas": maxPriorityFee.String(),
+	}).Debugln("using dynamic fees")
+	fees := &txFees{
+		GasLimit:             cmdSpec.GasLimitInt(e.root.Config),
+		BaseFee:              baseFee,
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: maxPriorityFee,
+	}
+	return fees, nil
+}
+
+// suggestPriorityFee returns the median of priority fees paid in the recent blocks.
+func (e *Executor) suggestPriorityFee(ctx model.AppContext) *big.Int {
+	history, err := e.feeHistory(ctx, feeHistoryBlocks, []float64{feeHistoryPercentile})
+	if err != nil {
+		log.WithError(err).Debugln("failed to get fee history, using the default priority fee")
+		return defaultPriorityFee
+	}
+	rewards := make([]*big.Int, 0, len(history.Reward))
+	for _, blockRewards := range history.Reward {
+		if len(blockRewards) > 0 && blockRewards[0] != nil {
+			rewards = append(rewards, blockRewards[0].ToInt())
+		}
+	}
+	if len(rewards) == 0 {
+		return defaultPriorityFee
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].Cmp(rewards[j]) < 0
+	})
+	return rewards[len(rewards)/2]
+}
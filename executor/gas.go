@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// GasUsage is the gas used by a mined transaction, along with its cost.
+type GasUsage struct {
+	TxHash            common.Hash
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	// Fee is the gas used at the effective gas price, in wei.
+	Fee *big.Int
+	// ContractAddress is set if the transaction has deployed a contract.
+	ContractAddress *common.Address
+}
+
+// gasUsage returns the gas used by the mined transaction. The effective gas price is taken from the receipt,
+// or from the transaction itself, if the node doesn't report it.
+func (e *Executor) gasUsage(ctx context.Context, receipt *txReceipt) *GasUsage {
+	usage := &GasUsage{
+		TxHash:            receipt.TxHash,
+		GasUsed:           uint64(receipt.GasUsed),
+		EffectiveGasPrice: new(big.Int),
+		ContractAddress:   receipt.ContractAddress,
+	}
+	if receipt.EffectiveGasPrice != nil {
+		usage.EffectiveGasPrice = receipt.EffectiveGasPrice.ToInt()
+	} else if tx, err := e.transactionByHash(ctx, receipt.TxHash); err != nil {
+		log.WithError(err).Warningln("failed to get the gas price of transaction")
+	} else if tx.GasPrice != nil {
+		usage.EffectiveGasPrice = tx.GasPrice.ToInt()
+	}
+	usage.Fee = new(big.Int).Mul(usage.EffectiveGasPrice, new(big.Int).SetUint64(usage.GasUsed))
+	return usage
+}
@@ -0,0 +1,290 @@
+package executor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AtlantPlatform/ethfw"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	yaml "github.com/xlab/yamlx"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// testPrivKey is the key of the alice wallet in test specs.
+const testPrivKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+var testAccount = crypto.PubkeyToAddress(mustPrivKey(testPrivKey).PublicKey)
+
+// testDeploySpec deploys the Token contract from the alice wallet.
+const testDeploySpec = `
+INVENTORY:
+  genesis:
+    - $URL
+WALLETS:
+  alice:
+    privkey: $PRIVKEY
+CONTRACTS:
+  Token:
+    name: Token
+    abi: Token.abi
+    bin: Token.bin
+    instances:
+      - contract: Token
+WRITE:
+  deploy-token:
+    wallet: alice
+    instance:
+      contract: Token
+TARGETS:
+  deploy:
+    - run: deploy-token
+      retries: 1
+      backoff: 10ms
+`
+
+// testTokenABI is the ABI of the Token contract, with a method to call and a view.
+const testTokenABI = `[
+	{"type": "function", "name": "mint", "inputs": [], "outputs": []},
+	{"type": "function", "name": "totalSupply", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "uint256"}]}
+]`
+
+// mockNode is a JSON-RPC node that mines each transaction into a new block as soon as it is sent.
+type mockNode struct {
+	// Reverted reports whether the n-th sent transaction fails
+	Reverted func(n int) bool
+	// Pending reports whether the n-th sent transaction is never mined
+	Pending func(n int) bool
+	// Lagging reports whether the n-th sent transaction is not found by the first lookup
+	Lagging func(n int) bool
+	// CallOutput is the output of eth_call, unless CallRevert is set, then the call fails with it as data
+	CallOutput string
+	CallRevert string
+
+	mux   sync.Mutex
+	block uint64
+	txs   []*mockTx
+	calls []*mockRequest
+}
+
+type mockTx struct {
+	hash    common.Hash
+	nonce   uint64
+	block   uint64
+	status  uint64
+	pending bool
+	lagging bool
+}
+
+// newMockNode starts the node, it must be closed after the test.
+func newMockNode() (*mockNode, *httptest.Server) {
+	node := &mockNode{
+		block: 1,
+	}
+	return node, httptest.NewServer(node)
+}
+
+type mockRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+}
+
+type mockResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *mockError      `json:"error,omitempty"`
+}
+
+type mockError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (node *mockNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reqs []*mockRequest
+		json.Unmarshal(body, &reqs)
+		resps := make([]*mockResponse, 0, len(reqs))
+		for _, req := range reqs {
+			resps = append(resps, node.handle(req))
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req *mockRequest
+	json.Unmarshal(body, &req)
+	json.NewEncoder(w).Encode(node.handle(req))
+}
+
+func (node *mockNode) handle(req *mockRequest) *mockResponse {
+	node.mux.Lock()
+	defer node.mux.Unlock()
+	node.calls = append(node.calls, req)
+	resp := &mockResponse{
+		Version: "2.0",
+		ID:      req.ID,
+	}
+	switch req.Method {
+	case "net_version":
+		resp.Result = "1"
+	case "eth_blockNumber":
+		resp.Result = hexutil.Uint64(node.block)
+	case "eth_getBlockByNumber":
+		number := node.block
+		if n, ok := req.Params[0].(string); ok && n != "latest" {
+			number = uint64(hexutil.MustDecodeUint64(n))
+		}
+		resp.Result = map[string]interface{}{
+			"hash":   mockBlockHash(number),
+			"number": hexutil.Uint64(number),
+		}
+	case "eth_gasPrice":
+		resp.Result = "0x3b9aca00"
+	case "eth_getBalance":
+		resp.Result = "0xde0b6b3a7640000"
+	case "eth_estimateGas":
+		resp.Result = "0x5208"
+	case "eth_getTransactionCount":
+		resp.Result = hexutil.Uint64(len(node.txs))
+	case "eth_getCode":
+		resp.Result = "0x6080"
+	case "eth_call":
+		if len(node.CallRevert) > 0 {
+			resp.Error = &mockError{
+				Code:    3,
+				Message: "execution reverted",
+				Data:    node.CallRevert,
+			}
+		} else if len(node.CallOutput) > 0 {
+			resp.Result = node.CallOutput
+		} else {
+			resp.Result = "0x"
+		}
+	case "eth_sendRawTransaction":
+		raw := hexutil.MustDecode(req.Params[0].(string))
+		n := len(node.txs)
+		tx := &mockTx{
+			hash:   crypto.Keccak256Hash(raw),
+			nonce:  uint64(n),
+			status: 1,
+		}
+		if node.Pending != nil && node.Pending(n) {
+			tx.pending = true
+		} else {
+			node.block++
+			tx.block = node.block
+		}
+		if node.Reverted != nil && node.Reverted(n) {
+			tx.status = 0
+		}
+		tx.lagging = node.Lagging != nil && node.Lagging(n)
+		node.txs = append(node.txs, tx)
+		resp.Result = tx.hash
+	case "eth_getTransactionByHash":
+		if tx := node.tx(req.Params[0]); tx != nil && tx.lagging {
+			tx.lagging = false
+		} else if tx != nil {
+			result := map[string]interface{}{
+				"hash":  tx.hash,
+				"from":  testAccount,
+				"nonce": hexutil.Uint64(tx.nonce),
+				"gas":   "0x5208",
+				"value": "0x0",
+				"input": "0x",
+			}
+			if !tx.pending {
+				result["blockNumber"] = hexutil.Uint64(tx.block)
+			}
+			resp.Result = result
+		}
+	case "eth_getTransactionReceipt":
+		if tx := node.tx(req.Params[0]); tx != nil && !tx.pending {
+			resp.Result = map[string]interface{}{
+				"transactionHash": tx.hash,
+				"blockHash":       mockBlockHash(tx.block),
+				"blockNumber":     hexutil.Uint64(tx.block),
+				"from":            testAccount,
+				"gasUsed":         "0x5208",
+				"status":          hexutil.Uint64(tx.status),
+				"logs":            []interface{}{},
+			}
+		}
+	default:
+		resp.Error = &mockError{
+			Code:    -32601,
+			Message: fmt.Sprintf("the method %s does not exist", req.Method),
+		}
+	}
+	return resp
+}
+
+func (node *mockNode) tx(hash interface{}) *mockTx {
+	for _, tx := range node.txs {
+		if strings.EqualFold(tx.hash.Hex(), fmt.Sprint(hash)) {
+			return tx
+		}
+	}
+	return nil
+}
+
+// Sent returns the number of transactions sent to the node.
+func (node *mockNode) Sent() int {
+	node.mux.Lock()
+	defer node.mux.Unlock()
+	return len(node.txs)
+}
+
+func mockBlockHash(number uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number + 1000))
+}
+
+func mustPrivKey(hex string) *ecdsa.PrivateKey {
+	pk, err := crypto.HexToECDSA(hex)
+	if err != nil {
+		panic(err)
+	}
+	return pk
+}
+
+// newTestExecutor validates the spec against the node, for the command or target with args.
+// The spec dir has the Token contract, the deployment state is kept there.
+func newTestExecutor(t *testing.T, dir, url, specYAML string, args ...string) (*Executor, model.AppContext, bool) {
+	for name, data := range map[string]string{"Token.abi": testTokenABI, "Token.bin": "6080"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	specYAML = strings.Replace(specYAML, "$URL", url, -1)
+	specYAML = strings.Replace(specYAML, "$PRIVKEY", testPrivKey, -1)
+	var spec model.Spec
+	if err := yaml.Unmarshal([]byte(specYAML), &spec); err != nil {
+		t.Fatal(err)
+	}
+	statePath := model.DeploymentStatePath(filepath.Join(dir, "playbook.yml"), "genesis")
+	ctx := model.NewAppContext(context.Background(), args[0], args, "genesis", dir, statePath, nil, ethfw.NewKeyCache())
+	if !spec.Validate(ctx) {
+		return nil, ctx, false
+	}
+	exec, err := New(ctx, &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exec, ctx, true
+}
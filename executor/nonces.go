@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// withNextNonce calls fn with the next nonce of the account, calls for the same account are serialized,
+// so transactions sent back-to-back don't race for the pending nonce of the node. The nonce is taken from
+// the node upon first use, and taken again if the node reports it's too low. Returns the nonce used.
+func (e *Executor) withNextNonce(ctx model.AppContext, account common.Address, fn func(nonce uint64) error) (uint64, error) {
+	var nonce uint64
+	err := e.nonces.Serialize(account, func() error {
+		if !e.isNonceSynced(account) {
+			if err := e.syncNonce(ctx, account); err != nil {
+				return err
+			}
+		}
+		for resynced := false; ; resynced = true {
+			nonce = e.nonces.Incr(account)
+			err := fn(nonce)
+			if err == nil {
+				return nil
+			}
+			// the nonce hasn't been used
+			e.nonces.Decr(account)
+			if resynced || !isNonceTooLowError(err) {
+				return err
+			}
+			log.WithFields(log.Fields{
+				"account": strings.ToLower(account.Hex()),
+				"nonce":   nonce,
+			}).Warningln("nonce is too low, syncing with the node")
+			e.nonces.Sync(account, func() (uint64, error) {
+				return e.ethCli.PendingNonceAt(ctx, account)
+			})
+		}
+	})
+	return nonce, err
+}
+
+func (e *Executor) syncNonce(ctx model.AppContext, account common.Address) error {
+	if ctx.IsSignOnly() {
+		err := fmt.Errorf("no nonce is given for wallet %s, use --nonces", e.root.Wallets.NameOf(account.Hex()))
+		return err
+	}
+	nonce, err := e.ethCli.PendingNonceAt(ctx, account)
+	if err != nil {
+		return err
+	}
+	e.setNonce(account, nonce)
+	return nil
+}
+
+func (e *Executor) setNonce(account common.Address, nonce uint64) {
+	e.nonces.Set(account, nonce)
+	e.noncesMux.Lock()
+	e.noncesSynced[account] = struct{}{}
+	e.noncesMux.Unlock()
+}
+
+func (e *Executor) isNonceSynced(account common.Address) bool {
+	e.noncesMux.Lock()
+	defer e.noncesMux.Unlock()
+	_, ok := e.noncesSynced[account]
+	return ok
+}
+
+func isNonceTooLowError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce is too low")
+}
@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// signOnlyFees resolves the fees without a node. Dynamic fees are used if maxFeePerGas is set,
+// otherwise the legacy gasPrice of config.
+func (e *Executor) signOnlyFees(cmdSpec *model.WriteCmdSpec) *txFees {
+	fees := &txFees{
+		GasLimit: cmdSpec.GasLimitInt(e.root.Config),
+	}
+	maxFee := cmdSpec.MaxFeePerGasInt(e.root.Config)
+	if maxFee == nil {
+		fees.GasPrice, _ = e.root.Config.GasPriceInt()
+		return fees
+	}
+	maxPriorityFee := cmdSpec.MaxPriorityFeePerGasInt(e.root.Config)
+	if maxPriorityFee == nil {
+		maxPriorityFee = defaultPriorityFee
+	}
+	if maxPriorityFee.Cmp(maxFee) > 0 {
+		maxPriorityFee = maxFee
+	}
+	fees.MaxFeePerGas = maxFee
+	fees.MaxPriorityFeePerGas = maxPriorityFee
+	return fees
+}
+
+// signTx signs the transaction of the command with the next nonce of the wallet, as given in sign-only mode.
+// The gas is not estimated: plain transfers take 21000, others take the gas limit.
+func (e *Executor) signTx(ctx model.AppContext, cmdSpec *model.WriteCmdSpec, wallet *model.WalletSpec, fees *txFees,
+	to *common.Address, value *big.Int, data []byte) (*model.SignedTx, error) {
+	account := common.HexToAddress(wallet.Address)
+	pk, err := e.privateKey(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	gasLimit := fees.GasLimit
+	if len(cmdSpec.GasLimit) == 0 && len(data) == 0 {
+		gasLimit = params.TxGas
+	}
+	var raw []byte
+	nonce, err := e.withNextNonce(ctx, account, func(nonce uint64) (err error) {
+		raw, err = e.signRawTx(pk, fees, nonce, gasLimit, to, value, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	chainID, _ := e.root.Config.ChainIDInt()
+	signed := &model.SignedTx{
+		Command: e.writeCmdName(cmdSpec),
+		From:    strings.ToLower(account.Hex()),
+		Nonce:   nonce,
+		Gas:     gasLimit,
+		ChainID: chainID.String(),
+		Hash:    strings.ToLower(crypto.Keccak256Hash(raw).Hex()),
+		Raw:     hexutil.Encode(raw),
+	}
+	if to != nil {
+		signed.To = strings.ToLower(to.Hex())
+	} else {
+		signed.Address = strings.ToLower(crypto.CreateAddress(account, nonce).Hex())
+	}
+	return signed, nil
+}
+
+// signOnlyResult saves the signed transaction into the file, instead of sending it.
+func (e *Executor) signOnlyResult(result *CommandResult, signed *model.SignedTx) []*CommandResult {
+	if err := e.signed.Add(signed); err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	log.WithFields(log.Fields{
+		"command": signed.Command,
+		"nonce":   signed.Nonce,
+		"file":    e.signed.Path(),
+	}).Infoln("transaction signed")
+	result.Result = "tx:" + signed.Hash
+	return []*CommandResult{result}
+}
+
+func (e *Executor) writeCmdName(cmdSpec *model.WriteCmdSpec) string {
+	for name, spec := range e.root.WriteCmds {
+		if spec == cmdSpec {
+			return name
+		}
+	}
+	return ""
+}
+
+// Broadcast submits the transactions signed offline one by one, each one is awaited to be mined.
+// Contract deployments are recorded into the deployment state.
+func (e *Executor) Broadcast(ctx model.AppContext, txs *model.SignedTxs, out chan<- []*CommandResult) {
+	defer close(out)
+
+	state := e.root.DeploymentState()
+	for _, tx := range txs.Transactions {
+		execLog := log.WithFields(log.Fields{
+			"command": tx.Command,
+			"tx":      tx.Hash,
+		})
+		result := &CommandResult{
+			Name:   tx.Command,
+			Wallet: tx.From,
+		}
+		raw, err := hexutil.Decode(tx.Raw)
+		if err == nil {
+			err = e.ethRPC.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(raw))
+		}
+		if err != nil && !isKnownTxError(err) {
+			result.Error = err
+			out <- []*CommandResult{result}
+			execLog.Errorln("stopping broadcast — tx sumbit failed")
+			return
+		}
+		if len(tx.Contract) > 0 && state != nil {
+			deployed := &model.DeployedInstance{
+				ID:       tx.InstanceID,
+				Instance: tx.Instance,
+				Address:  tx.Address,
+				TxHash:   tx.Hash,
+				Deployer: tx.From,
+			}
+			if err := state.Record(tx.Contract, deployed); err != nil {
+				execLog.WithError(err).Warningln("failed to save deployment state")
+			}
+		}
+		awaitTimeout, _ := e.root.Config.AwaitTimeoutDuration()
+		awaitCtx, cancelFn := ctx.WithTimeout(awaitTimeout)
+		receipt, err := e.awaitTx(awaitCtx, "tx:"+tx.Hash, e.root.Config.ConfirmationsInt())
+		cancelFn()
+		if err != nil {
+			result.Error = err
+			out <- []*CommandResult{result}
+			execLog.WithError(err).Errorln("stopping broadcast after await")
+			return
+		}
+		result.Result = "tx:" + tx.Hash
+		result.Events = e.decodeEvents(receipt.Logs)
+		out <- []*CommandResult{result}
+	}
+}
+
+// isKnownTxError reports whether the node already has the transaction, e.g. the broadcast is repeated.
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "known transaction") || strings.Contains(msg, "already known")
+}
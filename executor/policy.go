@@ -0,0 +1,206 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// TargetSummary lists the final statuses of the target steps, in order of completion.
+type TargetSummary struct {
+	Steps []*StepStatus
+	// Stopped is set if the target execution has been stopped by a failed step
+	Stopped bool
+
+	mux sync.Mutex
+}
+
+// StepStatus is the final status of a target step.
+type StepStatus struct {
+	Step     string
+	Attempts int
+	Skipped  bool
+	// NotRun is set if the step hasn't been started, since the target execution has been stopped
+	NotRun bool
+	// Failed is set if the last attempt of the step has failed,
+	// Ignored is set as well if the target went on regardless.
+	Failed  bool
+	Ignored bool
+	Error   error
+}
+
+func newTargetSummary() *TargetSummary {
+	return &TargetSummary{}
+}
+
+func (s *TargetSummary) add(status *StepStatus) {
+	s.mux.Lock()
+	s.Steps = append(s.Steps, status)
+	s.mux.Unlock()
+}
+
+func (s *TargetSummary) stop() {
+	s.mux.Lock()
+	s.Stopped = true
+	s.mux.Unlock()
+}
+
+// addNested adds the statuses of steps of the target invoked by a step, labeled by the step.
+// Failed steps are ignored as well, if the errors of the step are.
+func (s *TargetSummary) addNested(name string, nested *TargetSummary, ignored bool) {
+	for _, status := range nested.Steps {
+		status.Step = name + "/" + status.Step
+		if status.Failed && ignored {
+			status.Ignored = true
+		}
+		s.add(status)
+	}
+}
+
+// fail marks the step as failed after it's done, e.g. if its deferred transaction has failed.
+func (s *TargetSummary) fail(status *StepStatus, err error, ignored bool) {
+	s.mux.Lock()
+	status.Failed = true
+	status.Ignored = ignored
+	status.Error = err
+	s.mux.Unlock()
+}
+
+// HasFailed reports whether the target has been stopped, or any of its steps has failed without its errors ignored.
+func (s *TargetSummary) HasFailed() bool {
+	if s.Stopped {
+		return true
+	}
+	for _, status := range s.Steps {
+		if status.Failed && !status.Ignored {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClean reports whether all steps of the target have been done at the first attempt.
+func (s *TargetSummary) IsClean() bool {
+	if s.Stopped {
+		return false
+	}
+	for _, status := range s.Steps {
+		if status.Failed || status.Attempts > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *StepStatus) String() string {
+	switch {
+	case s.Ignored:
+		return "ignored"
+	case s.Failed:
+		return "failed"
+	case s.Skipped:
+		return "skipped"
+	case s.NotRun:
+		return "not run"
+	case s.Attempts > 1:
+		return "retried"
+	default:
+		return "done"
+	}
+}
+
+// notMinedError is the failure of a transaction without a receipt, e.g. not mined within the timeout,
+// or not found by a lagging node. Such steps are not retried, since the transaction may still get mined.
+type notMinedError struct {
+	timeout time.Duration
+	// err is set if the await has failed before the timeout
+	err error
+}
+
+func (err *notMinedError) Error() string {
+	if err.err != nil {
+		return fmt.Sprintf("transaction is not known to be mined: %v", err.err)
+	}
+	return fmt.Sprintf("transaction is not mined within %s", err.timeout)
+}
+
+// awaitFailure returns the failure of an awaited transaction that has no receipt.
+func awaitFailure(awaitCtx context.Context, timeout time.Duration, err error) error {
+	if awaitCtx.Err() == context.DeadlineExceeded {
+		return &notMinedError{timeout: timeout}
+	}
+	return &notMinedError{timeout: timeout, err: err}
+}
+
+// stepAttempt runs a step once, returns false if the target execution must be stopped.
+type stepAttempt func() ([]*CommandResult, bool)
+
+// runStep runs the step with its policies: the failed step is retried after the backoff, doubled for each retry,
+// unless its transaction has been sent but is not known to be mined,
+// and the step which errors are ignored doesn't stop the target. The final status of the step is added to the summary.
+// Returns false if the target execution must be stopped.
+func (e *Executor) runStep(ctx model.AppContext, targetName string, targetCmd model.TargetCommandSpec,
+	summary *TargetSummary, attempt stepAttempt) ([]*CommandResult, *StepStatus, bool) {
+	stepLog := log.WithFields(log.Fields{
+		"target":  targetName,
+		"command": targetCmd.Name(),
+	})
+	status := &StepStatus{
+		Step: targetCmd.Name(),
+	}
+	backoff := targetCmd.BackoffDuration()
+	var results []*CommandResult
+	var ok bool
+	for {
+		status.Attempts++
+		results, ok = attempt()
+		status.Error = stepError(results, ok)
+		if status.Error == nil || status.Attempts > targetCmd.RetriesInt() {
+			break
+		} else if _, notMined := status.Error.(*notMinedError); notMined {
+			break
+		}
+		stepLog.WithFields(log.Fields{
+			"attempt": status.Attempts,
+			"backoff": backoff.String(),
+		}).WithError(status.Error).Warningln("step has failed, retrying")
+		select {
+		case <-ctx.Done():
+			summary.add(status)
+			return results, status, false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	status.Skipped = isSkipped(results)
+	if status.Error != nil {
+		status.Failed = true
+		if targetCmd.IgnoreErrors {
+			stepLog.WithError(status.Error).Warningln("step has failed, its errors are ignored")
+			status.Ignored = true
+			ok = true
+		}
+	}
+	summary.add(status)
+	return results, status, ok
+}
+
+// stepError returns the error of a failed step, either the first error of its results,
+// or a generic one if the step has stopped the target.
+func stepError(results []*CommandResult, ok bool) error {
+	for _, result := range results {
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	if !ok {
+		return errors.New("step has failed")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryRevertedDeploy(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "deploy")
+	if !assert.True(ok) {
+		return
+	}
+	node.Reverted = func(n int) bool {
+		return n == 0
+	}
+	resultsC := make(chan []*CommandResult, 10)
+	summary, found := exec.RunTarget(ctx, "deploy", resultsC)
+	assert.True(found)
+	if assert.Len(summary.Steps, 1) {
+		assert.Equal(2, summary.Steps[0].Attempts)
+		assert.False(summary.Steps[0].Failed)
+		assert.NoError(summary.Steps[0].Error)
+	}
+	assert.False(summary.HasFailed())
+	assert.Equal(2, node.Sent())
+	// the reverted transaction of block 2 is replayed on the state before it
+	var replayed []interface{}
+	for _, call := range node.calls {
+		if call.Method == "eth_call" {
+			replayed = append(replayed, call.Params[1])
+		}
+	}
+	assert.Equal([]interface{}{"0x1"}, replayed)
+
+	instance := exec.root.Contracts["Token"].Instances[0]
+	assert.Equal(strings.ToLower(crypto.CreateAddress(testAccount, 1).Hex()), instance.Address)
+	deployed, ok := exec.root.DeploymentState().Instance("Token", "", 0)
+	if assert.True(ok) {
+		assert.Equal(instance.Address, deployed.Address)
+		assert.EqualValues(3, deployed.Block)
+	}
+}
+
+func TestLaggingDeployNotRetried(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "deploy")
+	if !assert.True(ok) {
+		return
+	}
+	// the node doesn't know the transaction yet, it may still get mined
+	node.Lagging = func(n int) bool {
+		return n == 0
+	}
+	resultsC := make(chan []*CommandResult, 10)
+	summary, found := exec.RunTarget(ctx, "deploy", resultsC)
+	assert.True(found)
+	if assert.Len(summary.Steps, 1) {
+		assert.Equal(1, summary.Steps[0].Attempts)
+		assert.True(summary.Steps[0].Failed)
+		assert.IsType(&notMinedError{}, summary.Steps[0].Error)
+	}
+	assert.True(summary.HasFailed())
+	assert.Equal(1, node.Sent())
+
+	instance := exec.root.Contracts["Token"].Instances[0]
+	assert.Equal(strings.ToLower(crypto.CreateAddress(testAccount, 0).Hex()), instance.Address)
+	deployed, ok := exec.root.DeploymentState().Instance("Token", "", 0)
+	if assert.True(ok) {
+		assert.Equal(instance.Address, deployed.Address)
+	}
+}
+
+func TestGraphStepsNotRun(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	spec := testDryRunSpec + `
+  graph:
+    - deploy-token
+    - run: mint
+      needs: [deploy-token]
+    - run: total-supply
+      needs: [mint]
+`
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, spec, "graph")
+	if !assert.True(ok) {
+		return
+	}
+	node.Reverted = func(n int) bool {
+		return true
+	}
+	resultsC := make(chan []*CommandResult, 10)
+	summary, found := exec.RunTarget(ctx, "graph", resultsC)
+	assert.True(found)
+	assert.True(summary.HasFailed())
+	statuses := make(map[string]string, len(summary.Steps))
+	for _, status := range summary.Steps {
+		statuses[status.Step] = status.String()
+	}
+	assert.Equal(map[string]string{
+		"deploy-token": "failed",
+		"mint":         "not run",
+		"total-supply": "not run",
+	}, statuses)
+}
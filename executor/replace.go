@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// ReplaceTx resubmits a pending transaction sent from one of the wallets, with the same nonce and bumped fees.
+// If cancel is set, the transaction is replaced with a zero-value transfer to the sender itself.
+func (e *Executor) ReplaceTx(ctx model.AppContext, txHash string, cancel bool) []*CommandResult {
+	result := &CommandResult{}
+	if len(common.FromHex(txHash)) != common.HashLength {
+		result.Error = fmt.Errorf("not a transaction hash: %s", txHash)
+		return []*CommandResult{result}
+	}
+	newTxHash, err := e.replaceTx(ctx, common.HexToHash(txHash), cancel)
+	if err != nil {
+		result.Error = err
+		return []*CommandResult{result}
+	}
+	result.Result = "tx:" + strings.ToLower(newTxHash.Hex())
+	return []*CommandResult{result}
+}
+
+func (e *Executor) replaceTx(ctx model.AppContext, txHash common.Hash, cancel bool) (common.Hash, error) {
+	tx, err := e.transactionByHash(ctx, txHash)
+	if err != nil {
+		return common.Hash{}, err
+	} else if tx.BlockNumber != nil {
+		err := fmt.Errorf("transaction is already mined in block %s", tx.BlockNumber.ToInt())
+		return common.Hash{}, err
+	}
+	from := strings.ToLower(tx.From.Hex())
+	wallet, ok := e.root.Wallets.WalletSpec(e.root.Wallets.NameOf(from))
+	if !ok {
+		err := fmt.Errorf("sender %s is not found among the wallets", from)
+		return common.Hash{}, err
+	}
+	pk, err := e.privateKey(wallet)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	fees, err := e.bumpFees(ctx, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	to, value, data := tx.To, tx.Value.ToInt(), []byte(tx.Input)
+	if cancel {
+		to, value, data = &tx.From, new(big.Int), nil
+		fees.GasLimit = params.TxGas
+	}
+	raw, err := e.signRawTx(pk, fees, uint64(tx.Nonce), fees.GasLimit, to, value, data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := e.ethRPC.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(raw)); err != nil {
+		return common.Hash{}, err
+	}
+	newTxHash := crypto.Keccak256Hash(raw)
+	if state := e.root.DeploymentState(); state != nil {
+		if cancel {
+			err = state.Forget(txHash.Hex())
+		} else {
+			err = state.Replace(txHash.Hex(), newTxHash.Hex())
+		}
+		if err != nil {
+			log.WithError(err).Warningln("failed to save deployment state")
+		}
+	}
+	if cancel && tx.To == nil {
+		// the contract won't be deployed
+		e.resetInstance(crypto.CreateAddress(tx.From, uint64(tx.Nonce)))
+	}
+	log.WithFields(log.Fields{
+		"tx":     strings.ToLower(txHash.Hex()),
+		"nonce":  uint64(tx.Nonce),
+		"cancel": cancel,
+	}).Infoln("transaction replaced with", strings.ToLower(newTxHash.Hex()))
+	return newTxHash, nil
+}
+
+// bumpFees returns the fees of a replacement for the pending transaction: its fees bumped by gasBumpPercent,
+// or the current fees of the network, whichever are higher. The fees are limited by gasBumpMaxFee.
+func (e *Executor) bumpFees(ctx model.AppContext, tx *rpcTransaction) (*txFees, error) {
+	percent := big.NewInt(100 + e.root.Config.GasBumpPercentInt())
+	bump := func(v *hexutil.Big) *big.Int {
+		if v == nil {
+			return new(big.Int)
+		}
+		// rounded up
+		bumped := new(big.Int).Mul(v.ToInt(), percent)
+		bumped.Add(bumped, big.NewInt(99))
+		return bumped.Div(bumped, big.NewInt(100))
+	}
+	fees := &txFees{
+		GasLimit: uint64(tx.Gas),
+	}
+	var maxPrice *big.Int
+	if tx.Type == hexutil.Uint64(dynamicFeeTxType) {
+		header, err := e.latestHeader(ctx)
+		if err != nil {
+			return nil, err
+		} else if header.BaseFee == nil {
+			return nil, errors.New("node reports no base fee for the dynamic-fee transaction")
+		}
+		baseFee := header.BaseFee.ToInt()
+		maxPriorityFee := bump(tx.MaxPriorityFeePerGas)
+		if suggested := e.suggestPriorityFee(ctx); suggested.Cmp(maxPriorityFee) > 0 {
+			maxPriorityFee = suggested
+		}
+		maxFee := bump(tx.MaxFeePerGas)
+		if current := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), maxPriorityFee); current.Cmp(maxFee) > 0 {
+			maxFee = current
+		}
+		fees.BaseFee = baseFee
+		fees.MaxFeePerGas = maxFee
+		fees.MaxPriorityFeePerGas = maxPriorityFee
+		maxPrice = maxFee
+	} else {
+		gasPrice := bump(tx.GasPrice)
+		if suggested, err := e.ethCli.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+			gasPrice = suggested
+		}
+		fees.GasPrice = gasPrice
+		maxPrice = gasPrice
+	}
+	if limit := e.root.Config.GasBumpMaxFeeInt(); limit != nil && maxPrice.Cmp(limit) > 0 {
+		err := fmt.Errorf("bumped fee %s exceeds gasBumpMaxFee %s", maxPrice, limit)
+		return nil, err
+	}
+	return fees, nil
+}
@@ -0,0 +1,263 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// RevertError is returned when a call or a transaction has been reverted,
+// carries the raw revert data and the reason decoded from it, if any.
+type RevertError struct {
+	Reason string
+	Data   []byte
+}
+
+func (err *RevertError) Error() string {
+	if len(err.Reason) > 0 {
+		return "execution reverted: " + err.Reason
+	} else if len(err.Data) > 0 {
+		return "execution reverted with unknown data: " + hexutil.Encode(err.Data)
+	}
+	return "execution reverted"
+}
+
+var (
+	// Error(string)
+	errorStringSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	// Panic(uint256)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+var panicCodes = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum conversion",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "too much memory allocated",
+	0x51: "call to zero-initialized function pointer",
+}
+
+// customError is a Solidity custom error declared in a contract ABI,
+// the ABI parser of go-ethereum ignores such entries, so they are parsed separately.
+type customError struct {
+	Name   string
+	Inputs abi.Arguments
+}
+
+func (c *customError) Signature() string {
+	types := make([]string, len(c.Inputs))
+	for i, input := range c.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", c.Name, strings.Join(types, ","))
+}
+
+// collectCustomErrors gathers custom errors from ABIs of all known contracts,
+// indexed by their 4-byte selectors.
+func collectCustomErrors(contracts model.Contracts) map[string]*customError {
+	customErrors := make(map[string]*customError)
+	for name, contract := range contracts {
+		src := contract.Source()
+		if src == nil {
+			continue
+		}
+		var entries []struct {
+			Type   string        `json:"type"`
+			Name   string        `json:"name"`
+			Inputs abi.Arguments `json:"inputs"`
+		}
+		if err := json.Unmarshal(src.ABI, &entries); err != nil {
+			log.WithError(err).WithField("contract", name).Warningln("failed to parse custom errors from ABI")
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type != "error" {
+				continue
+			}
+			c := &customError{
+				Name:   entry.Name,
+				Inputs: entry.Inputs,
+			}
+			selector := crypto.Keccak256([]byte(c.Signature()))[:4]
+			customErrors[string(selector)] = c
+		}
+	}
+	return customErrors
+}
+
+// decodeRevert decodes revert data into Error(string), Panic(uint256)
+// or one of the custom errors known from contract ABIs.
+func (e *Executor) decodeRevert(data []byte) *RevertError {
+	revertErr := &RevertError{
+		Data: data,
+	}
+	if len(data) < 4 {
+		return revertErr
+	}
+	selector, args := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, errorStringSelector):
+		stringType, _ := abi.NewType("string", nil)
+		values, err := abi.Arguments{{Type: stringType}}.UnpackValues(args)
+		if err == nil && len(values) == 1 {
+			revertErr.Reason = values[0].(string)
+		}
+	case bytes.Equal(selector, panicSelector):
+		if len(args) != 32 {
+			break
+		}
+		code := new(big.Int).SetBytes(args)
+		description, ok := panicCodes[code.Uint64()]
+		if !ok || !code.IsUint64() {
+			description = "unknown panic code"
+		}
+		revertErr.Reason = fmt.Sprintf("panic: %s (0x%x)", description, code)
+	default:
+		c, ok := e.customErrors[string(selector)]
+		if !ok {
+			break
+		}
+		values, err := c.Inputs.UnpackValues(args)
+		if err != nil {
+			break
+		}
+		fields := make([]string, len(values))
+		for i, value := range values {
+			if name := c.Inputs[i].Name; len(name) > 0 {
+				fields[i] = fmt.Sprintf("%s: %v", name, formatRevertValue(value))
+			} else {
+				fields[i] = fmt.Sprintf("%v", formatRevertValue(value))
+			}
+		}
+		revertErr.Reason = fmt.Sprintf("%s(%s)", c.Name, strings.Join(fields, ", "))
+	}
+	return revertErr
+}
+
+func formatRevertValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case common.Address:
+		return strings.ToLower(value.Hex())
+	case []byte:
+		return hexutil.Encode(value)
+	case [32]byte:
+		return hexutil.Encode(value[:])
+	}
+	return v
+}
+
+// explainRevert replays the call on the node at the specified block (nil for the latest)
+// to obtain the revert data from the error. Returns nil if the call succeeds or no revert data can be found,
+// the output of a successful call is never taken for revert data, even if it looks like one.
+func (e *Executor) explainRevert(ctx context.Context, args callArgs, block *big.Int) *RevertError {
+	blockArg := "latest"
+	if block != nil {
+		blockArg = hexutil.EncodeBig(block)
+	}
+	var out hexutil.Bytes
+	if err := e.ethRPC.CallContext(ctx, &out, "eth_call", args, blockArg); err != nil {
+		if data, ok := rpcErrorData(err); ok {
+			return e.decodeRevert(data)
+		}
+	}
+	return nil
+}
+
+// explainError replaces the error of a failed call or transaction with
+// the decoded revert, if the call reverts when replayed.
+func (e *Executor) explainError(ctx context.Context, args callArgs, err error) error {
+	if data, ok := rpcErrorData(err); ok {
+		return e.decodeRevert(data)
+	}
+	if revertErr := e.explainRevert(ctx, args, nil); revertErr != nil {
+		return revertErr
+	}
+	return err
+}
+
+// explainTx replays a mined transaction as a call on the state before its block
+// to find out why the transaction has failed.
+func (e *Executor) explainTx(ctx context.Context, txHash common.Hash) *RevertError {
+	tx, err := e.transactionByHash(ctx, txHash)
+	if err != nil {
+		log.WithError(err).Warningln("failed to fetch the transaction to decode revert")
+		return nil
+	}
+	args := callArgs{
+		From:  tx.From,
+		To:    tx.To,
+		Gas:   tx.Gas,
+		Value: tx.Value,
+		Data:  tx.Input,
+	}
+	var block *big.Int
+	if tx.BlockNumber != nil && tx.BlockNumber.ToInt().Sign() > 0 {
+		// the state at the block already has the transaction applied
+		block = new(big.Int).Sub(tx.BlockNumber.ToInt(), big.NewInt(1))
+	}
+	return e.explainRevert(ctx, args, block)
+}
+
+// rpcErrorData extracts revert data from a JSON-RPC error. The error type of the
+// RPC client is unexported, so its data field is accessed via reflection.
+func rpcErrorData(err error) ([]byte, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	field := v.FieldByName("Data")
+	if !field.IsValid() || !field.CanInterface() {
+		return nil, false
+	}
+	return revertDataValue(field.Interface())
+}
+
+func revertDataValue(v interface{}) ([]byte, bool) {
+	switch data := v.(type) {
+	case string:
+		// Parity-style nodes prefix data with "Reverted "
+		data = strings.TrimPrefix(data, "Reverted ")
+		if !strings.HasPrefix(data, "0x") {
+			return nil, false
+		}
+		decoded, err := hex.DecodeString(data[2:])
+		if err != nil {
+			return nil, false
+		}
+		return decoded, true
+	case map[string]interface{}:
+		// Ganache-style nodes report data per transaction hash
+		for _, key := range []string{"data", "return"} {
+			if value, ok := data[key]; ok {
+				return revertDataValue(value)
+			}
+		}
+		for _, value := range data {
+			if decoded, ok := revertDataValue(value); ok {
+				return decoded, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRevertData is the Error("paused") revert data.
+const testRevertData = "0x08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"0000000000000000000000000000000000000000000000000000000000000006" +
+	"7061757365640000000000000000000000000000000000000000000000000000"
+
+func TestExplainFailedDeploy(t *testing.T) {
+	assert := assert.New(t)
+
+	node, srv := newMockNode()
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	exec, ctx, ok := newTestExecutor(t, dir, srv.URL, testDeploySpec, "deploy-token")
+	if !assert.True(ok) {
+		return
+	}
+	node.Reverted = func(n int) bool {
+		return true
+	}
+	// the replay succeeds, its output is not revert data
+	node.CallOutput = testRevertData
+	results, _ := exec.RunCommand(ctx, "deploy-token")
+	if assert.Len(results, 1) && assert.Error(results[0].Error) {
+		assert.NotContains(results[0].Error.Error(), "paused")
+	}
+	// the replay fails with revert data
+	node.CallRevert = testRevertData
+	results, _ = exec.RunCommand(ctx, "deploy-token")
+	if assert.Len(results, 1) && assert.Error(results[0].Error) {
+		assert.Contains(results[0].Error.Error(), "paused")
+	}
+}
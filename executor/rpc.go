@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txReceipt is a transaction receipt as reported by the node. It is decoded manually,
+// because the receipt type of the vendored go-ethereum lacks the block fields.
+type txReceipt struct {
+	TxHash          common.Hash     `json:"transactionHash"`
+	BlockHash       common.Hash     `json:"blockHash"`
+	BlockNumber     hexutil.Uint64  `json:"blockNumber"`
+	From            common.Address  `json:"from"`
+	To              *common.Address `json:"to"`
+	ContractAddress *common.Address `json:"contractAddress"`
+	GasUsed         hexutil.Uint64  `json:"gasUsed"`
+	// not reported by nodes before the London fork
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	Status            hexutil.Uint64 `json:"status"`
+	Logs              []*types.Log   `json:"logs"`
+}
+
+func (e *Executor) transactionReceipt(ctx context.Context, txHash common.Hash) (*txReceipt, error) {
+	var receipt *txReceipt
+	if err := e.ethRPC.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, err
+	} else if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// rpcTransaction is a transaction as reported by the node, it is decoded manually,
+// so any transaction type is supported.
+type rpcTransaction struct {
+	Hash        common.Hash     `json:"hash"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Nonce       hexutil.Uint64  `json:"nonce"`
+	Gas         hexutil.Uint64  `json:"gas"`
+	Value       *hexutil.Big    `json:"value"`
+	Input       hexutil.Bytes   `json:"input"`
+	BlockNumber *hexutil.Big    `json:"blockNumber"`
+
+	Type                 hexutil.Uint64 `json:"type"`
+	GasPrice             *hexutil.Big   `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+}
+
+func (e *Executor) transactionByHash(ctx context.Context, txHash common.Hash) (*rpcTransaction, error) {
+	var tx *rpcTransaction
+	if err := e.ethRPC.CallContext(ctx, &tx, "eth_getTransactionByHash", txHash); err != nil {
+		return nil, err
+	} else if tx == nil {
+		return nil, ethereum.NotFound
+	}
+	return tx, nil
+}
+
+// callArgs are the arguments of eth_call and eth_estimateGas.
+type callArgs struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Gas   hexutil.Uint64  `json:"gas,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Data  hexutil.Bytes   `json:"data,omitempty"`
+}
+
+// rpcHeader is a block header as reported by the node, the header type
+// of the vendored go-ethereum lacks the base fee.
+type rpcHeader struct {
+	Hash    common.Hash    `json:"hash"`
+	Number  hexutil.Uint64 `json:"number"`
+	BaseFee *hexutil.Big   `json:"baseFeePerGas"`
+}
+
+func (e *Executor) latestHeader(ctx context.Context) (*rpcHeader, error) {
+	var header *rpcHeader
+	if err := e.ethRPC.CallContext(ctx, &header, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, err
+	} else if header == nil {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+// rpcFeeHistory is the result of eth_feeHistory.
+type rpcFeeHistory struct {
+	OldestBlock  hexutil.Uint64   `json:"oldestBlock"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	Reward       [][]*hexutil.Big `json:"reward"`
+}
+
+func (e *Executor) feeHistory(ctx context.Context, blocks int, percentiles []float64) (*rpcFeeHistory, error) {
+	var history *rpcFeeHistory
+	err := e.ethRPC.CallContext(ctx, &history, "eth_feeHistory", hexutil.Uint64(blocks), "latest", percentiles)
+	if err != nil {
+		return nil, err
+	} else if history == nil {
+		return nil, ethereum.NotFound
+	}
+	return history, nil
+}
@@ -0,0 +1,185 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// targetResults accumulates results of the target steps, so later steps may reference them.
+type targetResults struct {
+	root    *model.Spec
+	results map[string][]*CommandResult
+	mux     sync.RWMutex
+}
+
+func newTargetResults(root *model.Spec) *targetResults {
+	return &targetResults{
+		root:    root,
+		results: make(map[string][]*CommandResult),
+	}
+}
+
+func (t *targetResults) Add(name string, results []*CommandResult) {
+	t.mux.Lock()
+	t.results[name] = results
+	t.mux.Unlock()
+}
+
+// Has reports whether the step has been done.
+func (t *targetResults) Has(name string) bool {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	_, ok := t.results[name]
+	return ok
+}
+
+func (t *targetResults) ResolveStep(ref *model.StepReference) (interface{}, error) {
+	t.mux.RLock()
+	results, ok := t.results[ref.Step]
+	t.mux.RUnlock()
+	if !ok || len(results) == 0 {
+		err := fmt.Errorf("step %s has no results yet", ref.Step)
+		return nil, err
+	}
+	// the first result is used if the step ran for multiple wallets,
+	// or the first one not skipped if the step is a loop
+	result := results[0]
+	for _, r := range results {
+		if _, skipped := r.Result.(*Skipped); !skipped {
+			result = r
+			break
+		}
+	}
+	if result.Error != nil {
+		err := fmt.Errorf("step %s has failed: %v", ref.Step, result.Error)
+		return nil, err
+	}
+	if _, ok := result.Result.(*Skipped); ok {
+		err := fmt.Errorf("step %s has been skipped", ref.Step)
+		return nil, err
+	}
+	switch ref.Field {
+	case model.StepAddressField:
+		if sim, ok := result.Result.(*Simulation); ok && sim.ContractAddress != nil {
+			// would-be address of a simulated deployment
+			return *sim.ContractAddress, nil
+		}
+		return t.stepAddress(ref.Step)
+	case model.StepTxField:
+		if hash, ok := result.Result.(string); ok && strings.HasPrefix(hash, "tx:") {
+			return common.HexToHash(hash[3:]), nil
+		} else if deployment, ok := result.Result.(*Deployment); ok {
+			return deployment.TxHash, nil
+		}
+		err := fmt.Errorf("step %s has not sent a transaction", ref.Step)
+		return nil, err
+	case model.StepResultField:
+		if len(ref.Path) == 0 {
+			return result.Result, nil
+		}
+		v, err := selectPath(t.returnValues(ref.Step, result.Result), ref.Path)
+		if err != nil {
+			err = fmt.Errorf("%s: %v", ref, err)
+			return nil, err
+		}
+		return v, nil
+	}
+	err := fmt.Errorf("unknown step field: %s", ref.Field)
+	return nil, err
+}
+
+func (t *targetResults) stepAddress(step string) (common.Address, error) {
+	var instance *model.ContractInstanceSpec
+	if cmd, ok := t.root.WriteCmds[step]; ok {
+		if cmd.Instance == nil {
+			// plain transfer
+			return common.HexToAddress(cmd.To), nil
+		}
+		instance = cmd.Instance
+	} else if cmd, ok := t.root.ViewCmds[step]; ok {
+		instance = cmd.Instance
+	} else if cmd, ok := t.root.EventCmds[step]; ok {
+		instance = cmd.Instance
+	}
+	if instance == nil || !instance.IsDeployed() {
+		err := fmt.Errorf("step %s has no contract instance address", step)
+		return common.Address{}, err
+	}
+	return common.HexToAddress(instance.Address), nil
+}
+
+// returnValues presents the result of a VIEW step as a list of return values,
+// so that a single return value is selected by [0] as well.
+func (t *targetResults) returnValues(step string, result interface{}) interface{} {
+	cmd, ok := t.root.ViewCmds[step]
+	if !ok {
+		return result
+	}
+	method, ok := cmd.Instance.BoundContract().ABI().Methods[cmd.Method]
+	if ok && len(method.Outputs) == 1 {
+		return []interface{}{result}
+	}
+	return result
+}
+
+// selectPath selects an element of the value by int indices and string keys,
+// lists, arrays, maps and structs (by field name) are supported.
+func selectPath(v interface{}, path []interface{}) (interface{}, error) {
+	for _, elem := range path {
+		value := reflect.ValueOf(v)
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return nil, errors.New("cannot select from nil value")
+			}
+			value = value.Elem()
+		}
+		switch key := elem.(type) {
+		case int:
+			switch value.Kind() {
+			case reflect.Slice, reflect.Array:
+				if key >= value.Len() {
+					err := fmt.Errorf("index %d is out of range, length is %d", key, value.Len())
+					return nil, err
+				}
+				v = value.Index(key).Interface()
+			default:
+				err := fmt.Errorf("cannot index %T", v)
+				return nil, err
+			}
+		case string:
+			switch value.Kind() {
+			case reflect.Map:
+				if value.Type().Key().Kind() != reflect.String {
+					err := fmt.Errorf("cannot select key %s of %T", key, v)
+					return nil, err
+				}
+				item := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+				if !item.IsValid() {
+					err := fmt.Errorf("key %s is not found", key)
+					return nil, err
+				}
+				v = item.Interface()
+			case reflect.Struct:
+				field := value.FieldByNameFunc(func(name string) bool {
+					return strings.EqualFold(name, key)
+				})
+				if !field.IsValid() || !field.CanInterface() {
+					err := fmt.Errorf("field %s is not found", key)
+					return nil, err
+				}
+				v = field.Interface()
+			default:
+				err := fmt.Errorf("cannot select key %s of %T", key, v)
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// sendTx signs and sends a transaction from the wallet, with dynamic or legacy fees, the gas limit is estimated.
+// A nil recipient stands for contract creation. Returns the hash and the nonce of the sent transaction.
+func (e *Executor) sendTx(ctx model.AppContext, wallet *model.WalletSpec, fees *txFees,
+	to *common.Address, value *big.Int, data []byte) (common.Hash, uint64, error) {
+	account := common.HexToAddress(wallet.Address)
+	pk, err := e.privateKey(wallet)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	gasLimit := fees.GasLimit
+	estimatedGasLimit, err := e.ethCli.EstimateGas(ctx, ethereum.CallMsg{
+		From:  account,
+		To:    to,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil && len(data) > 0 {
+		// the call would fail
+		return common.Hash{}, 0, err
+	} else if err == nil && estimatedGasLimit < gasLimit {
+		gasLimit = estimatedGasLimit
+	}
+	var txHash common.Hash
+	nonce, err := e.withNextNonce(ctx, account, func(nonce uint64) error {
+		raw, err := e.signRawTx(pk, fees, nonce, gasLimit, to, value, data)
+		if err != nil {
+			return err
+		}
+		if err := e.ethRPC.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(raw)); err != nil {
+			return err
+		}
+		txHash = crypto.Keccak256Hash(raw)
+		return nil
+	})
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	return txHash, nonce, nil
+}
+
+// signRawTx signs the transaction for chainID of config and returns its binary encoding,
+// as accepted by eth_sendRawTransaction. It's a dynamic-fee transaction if the fees are dynamic.
+func (e *Executor) signRawTx(pk *ecdsa.PrivateKey, fees *txFees, nonce, gasLimit uint64,
+	to *common.Address, value *big.Int, data []byte) ([]byte, error) {
+	chainID, _ := e.root.Config.ChainIDInt()
+	if fees.Dynamic() {
+		tx := &dynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasTipCap:  fees.MaxPriorityFeePerGas,
+			GasFeeCap:  fees.MaxFeePerGas,
+			Gas:        gasLimit,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: []accessTuple{},
+		}
+		return tx.sign(pk)
+	}
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(nonce, value, gasLimit, fees.GasPrice, data)
+	} else {
+		tx = types.NewTransaction(nonce, *to, value, gasLimit, fees.GasPrice, data)
+	}
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), pk)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signedTx)
+}
+
+func (e *Executor) privateKey(wallet *model.WalletSpec) (*ecdsa.PrivateKey, error) {
+	account := common.HexToAddress(wallet.Address)
+	pk, ok := e.keycache.PrivateKey(account, wallet.Password)
+	if !ok {
+		if pk = wallet.PrivKeyECDSA(); pk == nil {
+			return nil, errors.New("failed to get account private key")
+		}
+		e.keycache.SetPrivateKey(account, pk)
+	}
+	return pk, nil
+}
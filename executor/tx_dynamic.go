@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// dynamicFeeTxType is the EIP-2718 type of EIP-1559 transactions.
+const dynamicFeeTxType byte = 0x02
+
+type accessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// dynamicFeeTx is an EIP-1559 transaction, the vendored go-ethereum
+// supports legacy transactions only, so it is encoded and signed manually.
+type dynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList []accessTuple
+
+	V, R, S *big.Int
+}
+
+func (tx *dynamicFeeTx) sigHash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes([]interface{}{
+		tx.ChainID,
+		tx.Nonce,
+		tx.GasTipCap,
+		tx.GasFeeCap,
+		tx.Gas,
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.AccessList,
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{dynamicFeeTxType}, payload), nil
+}
+
+// sign signs the transaction and returns its binary encoding, as accepted by eth_sendRawTransaction.
+func (tx *dynamicFeeTx) sign(pk *ecdsa.PrivateKey) ([]byte, error) {
+	h, err := tx.sigHash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(h[:], pk)
+	if err != nil {
+		return nil, err
+	}
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = new(big.Int).SetBytes(sig[64:])
+	payload, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{dynamicFeeTxType}, payload...), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/AtlantPlatform/ethereum-playbook/executor"
+	"github.com/AtlantPlatform/ethereum-playbook/model"
+)
+
+// addGasUsage adds the mined transactions of WRITE steps to the gas report.
+func addGasUsage(spec *model.Spec, report *model.GasReport, results []*executor.CommandResult) {
+	for _, result := range results {
+		if result.Gas == nil {
+			continue
+		}
+		step := &model.GasReportStep{
+			Command:  result.Name,
+			Wallet:   spec.Wallets.NameOf(result.Wallet),
+			TxHash:   strings.ToLower(result.Gas.TxHash.Hex()),
+			GasUsed:  result.Gas.GasUsed,
+			GasPrice: result.Gas.EffectiveGasPrice.String(),
+		}
+		if result.Gas.ContractAddress != nil {
+			step.Contract = strings.ToLower(result.Gas.ContractAddress.Hex())
+		}
+		report.Add(step, result.Gas.Fee)
+	}
+}
+
+// exportGasReportText prints the gas report as a table.
+func exportGasReportText(report *model.GasReport) {
+	fmt.Println("gas report:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tCOMMAND\tWALLET\tGAS USED\tGAS PRICE\tFEE (ETHER)\tCONTRACT")
+	for _, step := range report.Steps {
+		fmt.Fprintf(w, "\t%s\t%s\t%d\t%s\t%s\t%s\n", step.Command, step.Wallet,
+			step.GasUsed, step.GasPrice, step.Fee, step.Contract)
+	}
+	fmt.Fprintf(w, "\tTOTAL\t\t%d\t\t%s\t\n", report.TotalGasUsed, report.TotalFee)
+	w.Flush()
+}
+
+// checkGasReport prints the gas report, saves it if the path is given, and compares it with the baseline.
+// Returns false if there are gas regressions.
+func checkGasReport(cmdLog *log.Entry, report *model.GasReport, path string,
+	baseline *model.GasReport, threshold float64) bool {
+	exportGasReportText(report)
+	if len(path) > 0 {
+		if err := report.Save(path); err != nil {
+			cmdLog.WithError(err).Errorln("failed to save gas report")
+		}
+	}
+	if baseline == nil {
+		return true
+	}
+	regressions := report.Regressions(baseline, threshold)
+	for _, r := range regressions {
+		cmdLog.WithFields(log.Fields{
+			"command":  r.Command,
+			"baseline": r.Baseline,
+			"gasUsed":  r.GasUsed,
+		}).Errorf("gas use has grown by %.2f%%", r.Growth)
+	}
+	return len(regressions) == 0
+}
@@ -8,9 +8,13 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/AtlantPlatform/ethfw"
 	"github.com/AtlantPlatform/ethfw/sol"
@@ -28,6 +32,9 @@ var (
 	specPath  = flag.String("f", "playbook.yml", "Custom path to playbook.yml spec file.")
 	solcPath  = flag.String("s", "solc", "Name or path of Solidity compiler (solc, not solcjs).")
 	nodeGroup = flag.String("g", "genesis", "Inventory group name, corresponding to Geth nodes.")
+	dryRun    = flag.Bool("dry-run", false, "Simulate WRITE commands without sending transactions.")
+	signOnly  = flag.String("sign-only", "", "Sign WRITE transactions offline into the file, instead of sending them.")
+	nonces    = flag.String("nonces", "", "Starting nonces of wallets in sign-only mode, e.g. alice=5,bob=0.")
 	printHelp = flag.Bool("h", false, "Print help.")
 	logLevel  *int
 )
@@ -36,6 +43,9 @@ func init() {
 	app.StringOpt("f", "playbook.yml", "Custom path to playbook.yml spec file.")
 	app.StringOpt("s", "solc", "Name or path of Solidity compiler (solc, not solcjs).")
 	app.StringOpt("g", "genesis", "Inventory group name, corresponding to Geth nodes.")
+	app.BoolOpt("dry-run", false, "Simulate WRITE commands without sending transactions.")
+	app.StringOpt("sign-only", "", "Sign WRITE transactions offline into the file, instead of sending them.")
+	app.StringOpt("nonces", "", "Starting nonces of wallets in sign-only mode, e.g. alice=5,bob=0.")
 	app.BoolOpt("h", false, "Print help.")
 	logLevel = app.IntOpt("l log-level", 4, "Sets the log level (default: info)")
 }
@@ -125,20 +135,38 @@ func registerCommands(app *cli.Cli, spec *model.Spec) {
 		}
 		app.Command(name, desc, newCommand(spec, name, argCount))
 	}
+
+	eventCmdNames := make([]string, 0, len(spec.EventCmds))
+	for name := range spec.EventCmds {
+		eventCmdNames = append(eventCmdNames, name)
+	}
+	sort.Strings(eventCmdNames)
+	for _, name := range eventCmdNames {
+		cmd, _ := spec.EventCmds.EventCmdSpec(name)
+		desc := cmd.Description
+		argCount := cmd.ArgCount()
+		if len(desc) == 0 {
+			desc = fmt.Sprintf("Generic EVENTS command, accepts %d args", argCount)
+		}
+		app.Command(name, desc, newEventCommand(spec, name, argCount))
+	}
+
+	if !spec.HasCommand(broadcastCmd) {
+		app.Command(broadcastCmd, "Broadcast transactions signed in sign-only mode, awaiting each one", newBroadcast(spec))
+	}
+	if !spec.HasCommand(speedupCmd) {
+		app.Command(speedupCmd, "Resubmit a pending transaction with bumped fees", newReplace(spec, speedupCmd, false))
+	}
+	if !spec.HasCommand(cancelCmd) {
+		app.Command(cancelCmd, "Cancel a pending transaction with a zero-value transfer to the sender", newReplace(spec, cancelCmd, true))
+	}
 }
 
 func newCommand(spec *model.Spec, name string, argCount int) cli.CmdInitializer {
 	return func(cmd *cli.Cmd) {
-		args := make([]*string, argCount)
-		for i := 0; i < argCount; i++ {
-			args[i] = cmd.StringArg(fmt.Sprintf("ARG%d", i+1), "", fmt.Sprintf("Command argument $%d", i+1))
-		}
+		appArgs := registerArgs(cmd, spec, name, argCount, "Command")
 		cmd.Action = func() {
-			appArgs := []string{name}
-			for _, arg := range args {
-				appArgs = append(appArgs, *arg)
-			}
-			ctx := validateSpec(spec, name, appArgs)
+			ctx := validateSpec(spec, name, appArgs())
 			cmdLog := log.WithFields(log.Fields{
 				"command": name,
 			})
@@ -155,43 +183,216 @@ func newCommand(spec *model.Spec, name string, argCount int) cli.CmdInitializer
 	}
 }
 
-func newTarget(spec *model.Spec, name string, argCount int) cli.CmdInitializer {
+func newEventCommand(spec *model.Spec, name string, argCount int) cli.CmdInitializer {
 	return func(cmd *cli.Cmd) {
-		args := make([]*string, argCount)
-		for i := 0; i < argCount; i++ {
-			args[i] = cmd.StringArg(fmt.Sprintf("ARG%d", i+1), "", fmt.Sprintf("Target argument $%d", i+1))
-		}
+		watch := cmd.BoolOpt("w watch", false, "Stream new events as they arrive, until interrupted")
+		appArgs := registerArgs(cmd, spec, name, argCount, "Command")
 		cmd.Action = func() {
-			appArgs := []string{name}
-			for _, arg := range args {
-				appArgs = append(appArgs, *arg)
+			ctx := validateSpec(spec, name, appArgs())
+			cmdLog := log.WithFields(log.Fields{
+				"command": name,
+			})
+			exec, err := executor.New(ctx, spec)
+			if err != nil {
+				cmdLog.WithError(err).Fatalln("failed to init executor")
+			}
+			if !*watch {
+				results, _ := exec.RunCommand(ctx, name)
+				exportResultsText(spec, results, "")
+				return
 			}
-			ctx := validateSpec(spec, name, appArgs)
+			watchCtx, cancelFn := context.WithCancel(ctx)
+			defer cancelFn()
+			go func() {
+				sigC := make(chan os.Signal, 1)
+				signal.Notify(sigC, os.Interrupt, syscall.SIGTERM)
+				<-sigC
+				cmdLog.Infoln("interrupted, stopping the watch")
+				cancelFn()
+			}()
+			resultsC := make(chan []*executor.CommandResult, 100)
+			wg := new(sync.WaitGroup)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for results := range resultsC {
+					exportEventsText(results)
+				}
+			}()
+			exec.WatchEvents(model.AppContext{Context: watchCtx}, name, resultsC)
+			wg.Wait()
+		}
+	}
+}
+
+func newTarget(spec *model.Spec, name string, argCount int) cli.CmdInitializer {
+	return func(cmd *cli.Cmd) {
+		appArgs := registerArgs(cmd, spec, name, argCount, "Target")
+		gasReportPath := cmd.StringOpt("gas-report", "", "Save the gas usage report of the target into the JSON file")
+		gasBaselinePath := cmd.StringOpt("gas-baseline", "", "Gas usage report of an earlier run, to check for gas regressions")
+		gasThreshold := cmd.IntOpt("gas-threshold", 5, "Growth of gas use in percent of the baseline, that is a regression")
+		cmd.Action = func() {
+			ctx := validateSpec(spec, name, appArgs())
 			cmdLog := log.WithFields(log.Fields{
 				"target": name,
 			})
+			var baseline *model.GasReport
+			if len(*gasBaselinePath) > 0 {
+				report, err := model.LoadGasReport(*gasBaselinePath)
+				if err != nil {
+					cmdLog.WithError(err).Fatalln("failed to load gas baseline")
+				}
+				baseline = report
+			}
 			exec, err := executor.New(ctx, spec)
 			if err != nil {
 				cmdLog.WithError(err).Fatalln("failed to init executor")
 			}
+			gasReport := model.NewGasReport(name)
 			resultsC := make(chan []*executor.CommandResult, 100)
 			wg := new(sync.WaitGroup)
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				for results := range resultsC {
-					fmt.Printf("%s:\n", results[0].Name)
-					exportResultsText(spec, results, "\t")
+					// iterations of a loop step are labeled each
+					for _, group := range groupByName(results) {
+						fmt.Printf("%s:\n", group[0].Name)
+						exportResultsText(spec, group, "\t")
+					}
+					addGasUsage(spec, gasReport, results)
 				}
 			}()
-			if found := exec.RunTarget(ctx, name, resultsC); !found {
+			summary, found := exec.RunTarget(ctx, name, resultsC)
+			if !found {
 				cmdLog.Fatalln("target not found")
 			}
 			wg.Wait()
+			if !summary.IsClean() {
+				exportTargetSummaryText(summary)
+			}
+			if len(gasReport.Steps) > 0 && !checkGasReport(cmdLog, gasReport,
+				*gasReportPath, baseline, float64(*gasThreshold)) {
+				os.Exit(1)
+			}
+			if summary.HasFailed() {
+				cmdLog.Errorln("target has failed")
+				os.Exit(1)
+			}
 		}
 	}
 }
 
+const (
+	broadcastCmd = "broadcast"
+	speedupCmd   = "speedup"
+	cancelCmd    = "cancel"
+)
+
+func newBroadcast(spec *model.Spec) cli.CmdInitializer {
+	return func(cmd *cli.Cmd) {
+		path := cmd.StringArg("FILE", "", "File of transactions signed in sign-only mode")
+		cmd.Action = func() {
+			cmdLog := log.WithFields(log.Fields{
+				"file": *path,
+			})
+			if len(*signOnly) > 0 {
+				cmdLog.Fatalln("cannot broadcast in sign-only mode")
+			}
+			txs, err := model.LoadSignedTxs(*path)
+			if err != nil {
+				cmdLog.WithError(err).Fatalln("failed to load signed transactions")
+			}
+			ctx := validateSpec(spec, broadcastCmd, []string{broadcastCmd})
+			exec, err := executor.New(ctx, spec)
+			if err != nil {
+				cmdLog.WithError(err).Fatalln("failed to init executor")
+			}
+			resultsC := make(chan []*executor.CommandResult, 100)
+			wg := new(sync.WaitGroup)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for results := range resultsC {
+					fmt.Printf("%s:\n", results[0].Name)
+					exportResultsText(spec, results, "\t")
+				}
+			}()
+			exec.Broadcast(ctx, txs, resultsC)
+			wg.Wait()
+		}
+	}
+}
+
+func newReplace(spec *model.Spec, name string, cancel bool) cli.CmdInitializer {
+	return func(cmd *cli.Cmd) {
+		txHash := cmd.StringArg("TXHASH", "", "Hash of the pending transaction, sent from one of the wallets")
+		cmd.Action = func() {
+			ctx := validateSpec(spec, name, []string{name})
+			cmdLog := log.WithFields(log.Fields{
+				"command": name,
+			})
+			if ctx.DryRun() || ctx.IsSignOnly() {
+				cmdLog.Fatalln("pending transactions cannot be replaced in dry-run or sign-only mode")
+			}
+			exec, err := executor.New(ctx, spec)
+			if err != nil {
+				cmdLog.WithError(err).Fatalln("failed to init executor")
+			}
+			exportResultsText(spec, exec.ReplaceTx(ctx, *txHash, cancel), "")
+		}
+	}
+}
+
+// registerArgs registers CLI arguments of the command or target. Declared args are exposed by name,
+// as options if they have default values, the others as positional ARG1..N.
+func registerArgs(cmd *cli.Cmd, spec *model.Spec, name string, argCount int, kind string) func() []string {
+	argSpecs, err := spec.ArgSpecs(name)
+	discardLog := log.New()
+	discardLog.Out = ioutil.Discard
+	if err != nil || !argSpecs.Validate(log.NewEntry(discardLog)) {
+		// will be reported upon validation
+		argSpecs = nil
+	}
+	// args bound by loop steps of a target are optional
+	var loopArgs map[int]struct{}
+	if target, ok := spec.Targets.TargetSpec(name); ok {
+		loopArgs = target.LoopArgs(spec)
+	}
+	args := make([]*string, argCount)
+	for i := 0; i < argCount; i++ {
+		_, isLoopArg := loopArgs[i+1]
+		if i >= len(argSpecs) {
+			desc := fmt.Sprintf("%s argument $%d", kind, i+1)
+			if isLoopArg {
+				args[i] = cmd.StringOpt(fmt.Sprintf("arg%d", i+1), "", desc+" (bound by loops)")
+				continue
+			}
+			args[i] = cmd.StringArg(fmt.Sprintf("ARG%d", i+1), "", desc)
+			continue
+		}
+		arg := argSpecs[i]
+		desc := fmt.Sprintf("%s argument $%s (%s)", kind, arg.Name, arg.ParamType())
+		if len(arg.Description) > 0 {
+			desc = fmt.Sprintf("%s (%s)", arg.Description, arg.ParamType())
+		}
+		if arg.HasDefault() {
+			args[i] = cmd.StringOpt(arg.Name, arg.DefaultValue(), desc)
+		} else if isLoopArg {
+			args[i] = cmd.StringOpt(arg.Name, "", desc+" (bound by loops)")
+		} else {
+			args[i] = cmd.StringArg(strings.ToUpper(arg.Name), "", desc)
+		}
+	}
+	return func() []string {
+		appArgs := []string{name}
+		for _, arg := range args {
+			appArgs = append(appArgs, *arg)
+		}
+		return appArgs
+	}
+}
+
 func loadSpec() (*model.Spec, bool) {
 	var spec *model.Spec
 	specLog := log.WithFields(log.Fields{
@@ -234,8 +435,23 @@ func validateSpec(spec *model.Spec, appCommand string, appArgs []string) model.A
 		}
 		solcCompiler = compiler
 	}
+	statePath := model.DeploymentStatePath(filepath.Join(spec.Config.SpecDir, filepath.Base(*specPath)), *nodeGroup)
 	ctx := model.NewAppContext(context.Background(), appCommand, appArgs, *nodeGroup,
-		spec.Config.SpecDir, solcCompiler, ethfw.NewKeyCache())
+		spec.Config.SpecDir, statePath, solcCompiler, ethfw.NewKeyCache())
+	// conflicting args are reported upon validation
+	argSpecs, _ := spec.ArgSpecs(appCommand)
+	ctx = ctx.WithArgNames(argSpecs.Positions())
+	ctx = ctx.WithDryRun(*dryRun)
+	if len(*signOnly) > 0 {
+		if *dryRun {
+			specLog.Fatalln("dry-run and sign-only modes cannot be used together")
+		}
+		walletNonces, err := model.ParseNonces(*nonces)
+		if err != nil {
+			specLog.WithError(err).Fatalln("failed to parse wallet nonces")
+		}
+		ctx = ctx.WithSignOnly(*signOnly, walletNonces)
+	}
 	if ok := spec.Validate(ctx); !ok {
 		os.Exit(-1)
 	}
@@ -254,7 +470,7 @@ func exportResultsText(spec *model.Spec, results []*executor.CommandResult, padd
 				fmt.Println(padding + text)
 				return
 			}
-			text := jsonPaddedString(prettify(results[0].Result), padding)
+			text := jsonPaddedString(prettifyResult(results[0]), padding)
 			fmt.Println(padding + text)
 			return
 		}
@@ -266,11 +482,56 @@ func exportResultsText(spec *model.Spec, results []*executor.CommandResult, padd
 			fmt.Printf("%s%s (@%s): %s\n", padding, result.Wallet, walletName, text)
 			continue
 		}
-		text := jsonPaddedString(prettify(result.Result), padding)
+		text := jsonPaddedString(prettifyResult(result), padding)
 		fmt.Printf("%s%s (@%s): %s\n", padding, result.Wallet, walletName, text)
 	}
 }
 
+// exportTargetSummaryText prints the statuses of the target steps as a table.
+func exportTargetSummaryText(summary *executor.TargetSummary) {
+	fmt.Println("target summary:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tSTEP\tSTATUS\tATTEMPTS\tERROR")
+	for _, status := range summary.Steps {
+		var errText string
+		if status.Error != nil {
+			errText = status.Error.Error()
+		}
+		fmt.Fprintf(w, "\t%s\t%s\t%d\t%s\n", status.Step, status, status.Attempts, errText)
+	}
+	w.Flush()
+	if summary.Stopped {
+		fmt.Println("\ttarget execution has been stopped")
+	}
+}
+
+// groupByName splits the results into groups of consecutive results with the same name.
+func groupByName(results []*executor.CommandResult) [][]*executor.CommandResult {
+	var groups [][]*executor.CommandResult
+	for i, result := range results {
+		if i == 0 || result.Name != results[i-1].Name {
+			groups = append(groups, []*executor.CommandResult{result})
+			continue
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], result)
+	}
+	return groups
+}
+
+// exportEventsText prints streamed events one by one.
+func exportEventsText(results []*executor.CommandResult) {
+	for _, result := range results {
+		if result.Error != nil {
+			text := jsonPaddedString(&ErrorObject{Error: result.Error.Error()}, "")
+			fmt.Println(text)
+			continue
+		}
+		for _, ev := range prettifyEvents(result.Events) {
+			fmt.Println(jsonPaddedString(ev, ""))
+		}
+	}
+}
+
 func jsonPaddedString(v interface{}, padding string) string {
 	vv, err := json.MarshalIndent(v, padding, "\t")
 	if err != nil {
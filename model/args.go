@@ -0,0 +1,189 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ArgSpec declares a named command argument, referenced as $name in params and values.
+// Arguments with a default value are optional and exposed as CLI options.
+type ArgSpec struct {
+	Name        string      `yaml:"name"`
+	Type        ParamType   `yaml:"type"`
+	Default     interface{} `yaml:"default"`
+	Description string      `yaml:"desc"`
+}
+
+// ArgSpecs is the ordered list of named arguments, the N-th argument is also available as $N.
+type ArgSpecs []*ArgSpec
+
+var argNameRx = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParamType returns the type of the arg, string if not specified.
+func (spec *ArgSpec) ParamType() ParamType {
+	if len(spec.Type) == 0 {
+		return ParamTypeString
+	}
+	return spec.Type
+}
+
+func (spec *ArgSpec) HasDefault() bool {
+	return spec.Default != nil
+}
+
+func (spec *ArgSpec) DefaultValue() string {
+	return nillableStr(spec.Default)
+}
+
+func (specs ArgSpecs) Validate(validateLog *log.Entry) bool {
+	names := make(map[string]struct{}, len(specs))
+	for i, spec := range specs {
+		if spec == nil || len(spec.Name) == 0 {
+			validateLog.WithField("offset", i).Errorln("arg name is not specified")
+			return false
+		}
+		argLog := validateLog.WithField("arg", spec.Name)
+		if !argNameRx.MatchString(spec.Name) {
+			argLog.Errorln("arg name must start with a letter and contain only letters, digits and underscores")
+			return false
+		} else if _, ok := names[spec.Name]; ok {
+			argLog.Errorln("arg name is not unique")
+			return false
+		}
+		names[spec.Name] = struct{}{}
+	}
+	return true
+}
+
+// ValidateValues checks the provided (or default) values of the arguments against their types.
+func (specs ArgSpecs) ValidateValues(ctx AppContext, validateLog *log.Entry, root *Spec) bool {
+	args := ctx.AppCommandArgs()
+	evaler := NewEvaler()
+	for i, spec := range specs {
+		if i+1 >= len(args) {
+			break
+		}
+		value := args[i+1]
+		if isWalletRef(value) && value[1:] == walletPrefix {
+			// resolved for each of the matching wallets
+			continue
+		}
+		if _, err := parseCompositeElem(ctx, root, evaler, spec.ParamType(), value); err != nil {
+			validateLog.WithFields(log.Fields{
+				"arg":   spec.Name,
+				"type":  string(spec.ParamType()),
+				"value": value,
+			}).WithError(err).Errorln("arg value doesn't match its type")
+			return false
+		}
+	}
+	return true
+}
+
+// Positions maps the arg names to their positions in the command args.
+func (specs ArgSpecs) Positions() map[string]int {
+	positions := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		if spec != nil {
+			positions[spec.Name] = i + 1
+		}
+	}
+	return positions
+}
+
+func (specs ArgSpecs) CountArgsUsing(set map[int]struct{}) {
+	for i := range specs {
+		set[i+1] = struct{}{}
+	}
+}
+
+// ArgSpecs returns the named arguments of the command, or those of the commands
+// listed in the target, including the targets it forwards the args to.
+// Commands of a target must agree on the arguments they declare.
+func (spec *Spec) ArgSpecs(name string) (ArgSpecs, error) {
+	target, ok := spec.Targets.TargetSpec(name)
+	if !ok {
+		return spec.cmdArgSpecs(name), nil
+	}
+	var merged ArgSpecs
+	declaredBy := make(map[int]string)
+	var cmdNames []string
+	for _, target := range spec.expandTarget(target, true) {
+		cmdNames = append(cmdNames, target.CmdNames()...)
+	}
+	for _, cmdName := range cmdNames {
+		for i, arg := range spec.cmdArgSpecs(cmdName) {
+			if i >= len(merged) {
+				merged = append(merged, make(ArgSpecs, i+1-len(merged))...)
+			}
+			if merged[i] == nil {
+				merged[i] = arg
+				declaredBy[i] = cmdName
+				continue
+			}
+			if merged[i].Name != arg.Name || merged[i].ParamType() != arg.ParamType() {
+				err := fmt.Errorf("arg $%d is declared as %s (%s) by %s and as %s (%s) by %s",
+					i+1, merged[i].Name, merged[i].ParamType(), declaredBy[i], arg.Name, arg.ParamType(), cmdName)
+				return nil, err
+			}
+		}
+	}
+	for i, arg := range merged {
+		if arg == nil {
+			err := fmt.Errorf("arg $%d is not declared by the commands of the target", i+1)
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+func (spec *Spec) cmdArgSpecs(name string) ArgSpecs {
+	if cmd, ok := spec.CallCmds[name]; ok {
+		return cmd.Args
+	} else if cmd, ok := spec.ViewCmds[name]; ok {
+		return cmd.Args
+	} else if cmd, ok := spec.WriteCmds[name]; ok {
+		return cmd.Args
+	} else if cmd, ok := spec.EventCmds[name]; ok {
+		return cmd.Args
+	}
+	return nil
+}
+
+// validateArgs validates the args of the command to run, and their values.
+func (spec *Spec) validateArgs(ctx AppContext) bool {
+	cmdName := ctx.AppCommand()
+	if len(cmdName) == 0 {
+		return true
+	}
+	validateLog := log.WithFields(log.Fields{
+		"section": "Args",
+		"command": cmdName,
+	})
+	specs, err := spec.ArgSpecs(cmdName)
+	if err != nil {
+		validateLog.WithError(err).Errorln("conflicting args")
+		return false
+	} else if !specs.Validate(validateLog) {
+		return false
+	}
+	return specs.ValidateValues(ctx, validateLog, spec)
+}
+
+// WithArgNames returns a context where the command args can be referenced by name.
+func (ctx AppContext) WithArgNames(positions map[string]int) AppContext {
+	return AppContext{context.WithValue(ctx.Context, "argnames", positions)}
+}
+
+func (ctx AppContext) ArgNames() map[string]int {
+	positions, _ := ctx.Value("argnames").(map[string]int)
+	return positions
+}
+
+// WithArgs returns a context where the command args are replaced, e.g. with the values bound by a loop step.
+func (ctx AppContext) WithArgs(args []string) AppContext {
+	return AppContext{context.WithValue(ctx.Context, "args", args)}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgSpecs(t *testing.T) {
+	assert := assert.New(t)
+
+	validateLog := log.WithField("test", "TestArgSpecs")
+	specs := ArgSpecs{
+		{Name: "to", Type: ParamTypeAddress},
+		{Name: "amount", Type: ParamTypeUInt, Default: "1e18"},
+		{Name: "memo"},
+	}
+	assert.True(specs.Validate(validateLog))
+	assert.Equal(ParamTypeString, specs[2].ParamType())
+	assert.True(specs[1].HasDefault())
+	assert.Equal("1e18", specs[1].DefaultValue())
+	assert.Equal(map[string]int{"to": 1, "amount": 2, "memo": 3}, specs.Positions())
+
+	assert.False(ArgSpecs{{Name: "to"}, {Name: "to"}}.Validate(validateLog))
+	assert.False(ArgSpecs{{Name: "1st"}}.Validate(validateLog))
+	assert.False(ArgSpecs{{Name: "max-fee"}}.Validate(validateLog))
+	assert.False(ArgSpecs{{}}.Validate(validateLog))
+
+	ctx := NewAppContext(context.Background(), "send", []string{"send",
+		"0x5e2b23eeab4d0a6e79578d3479a6a37466a34a4c", "2 * 1e18", "hello"},
+		"genesis", "", "", nil, nil)
+	assert.True(specs.ValidateValues(ctx, validateLog, &Spec{}))
+	ctx = NewAppContext(context.Background(), "send", []string{"send",
+		"0x5e2b23eeab4d0a6e79578d3479a6a37466a34a4c", "-1", "hello"},
+		"genesis", "", "", nil, nil)
+	assert.False(specs.ValidateValues(ctx, validateLog, &Spec{}))
+}
+
+func TestSpecArgSpecs(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := &Spec{
+		CallCmds: CallCmds{
+			"txinfo": {Args: ArgSpecs{{Name: "tx"}}},
+		},
+		ViewCmds: ViewCmds{
+			"balance": {Args: ArgSpecs{{Name: "tx", Type: ParamTypeString}, {Name: "holder", Type: ParamTypeAddress}}},
+			"owner":   {Args: ArgSpecs{{Name: "holder", Type: ParamTypeAddress}}},
+		},
+		Targets: Targets{
+			"info":     TargetSpec{{Run: "txinfo"}, {Run: "balance"}},
+			"conflict": TargetSpec{{Run: "balance"}, {Run: "owner"}},
+		},
+	}
+	specs, err := spec.ArgSpecs("balance")
+	if assert.NoError(err) {
+		assert.Len(specs, 2)
+	}
+	specs, err = spec.ArgSpecs("info")
+	if assert.NoError(err) {
+		assert.Equal(map[string]int{"tx": 1, "holder": 2}, specs.Positions())
+	}
+	_, err = spec.ArgSpecs("conflict")
+	assert.Error(err)
+
+	ctx := NewAppContext(context.Background(), "info", []string{"info", "0x01", "0x02"},
+		"genesis", "", "", nil, nil).WithArgNames(specs.Positions())
+	ref, err := newArgReference(ctx, "$holder")
+	if assert.NoError(err) {
+		assert.Equal(2, ref.ArgID)
+	}
+	_, err = newArgReference(ctx, "$amount")
+	assert.Error(err)
+	str, err := substituteArgRefs(ctx, "$tx + $2")
+	if assert.NoError(err) {
+		assert.Equal("0x01 + 0x02", str)
+	}
+	_, err = substituteArgRefs(ctx, "$tx + $3")
+	assert.Equal(&MissingArg{Ref: "$3"}, err)
+	v, ok := parseParam(NewEvaler(), ParamTypeUInt, str)
+	if assert.True(ok) {
+		assert.Equal(big.NewInt(3), v)
+	}
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/AtlantPlatform/ethfw/sol"
+)
+
+// loadABIContract creates a contract from separate ABI (JSON) and bytecode (hex) files,
+// bytecode may be omitted for contracts that are not going to be deployed.
+func loadABIContract(name, abiPath, binPath string) (*sol.Contract, error) {
+	abiData, err := ioutil.ReadFile(abiPath)
+	if err != nil {
+		return nil, err
+	}
+	abiData = bytes.TrimSpace(abiData)
+	if !json.Valid(abiData) {
+		err := fmt.Errorf("ABI file contains invalid JSON: %s", abiPath)
+		return nil, err
+	}
+	contract := &sol.Contract{
+		Name:       name,
+		SourcePath: abiPath,
+		ABI:        abiData,
+	}
+	if len(binPath) == 0 {
+		return contract, nil
+	}
+	binData, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := normalizeBytecode(string(binData))
+	if err != nil {
+		err = fmt.Errorf("bytecode file %s: %v", binPath, err)
+		return nil, err
+	}
+	contract.Bin = bin
+	return contract, nil
+}
+
+// artifactJSON covers the compilation artifacts produced by Truffle, Hardhat and Foundry.
+// The bytecode is either a hex string (Truffle, Hardhat) or an object with hex string
+// in the 'object' field (Foundry).
+type artifactJSON struct {
+	ContractName string          `json:"contractName"`
+	ABI          json.RawMessage `json:"abi"`
+	Bytecode     json.RawMessage `json:"bytecode"`
+}
+
+type artifactBytecodeJSON struct {
+	Object string `json:"object"`
+}
+
+// loadArtifactContract creates a contract from a JSON artifact file.
+func loadArtifactContract(name, path string) (*sol.Contract, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var artifact artifactJSON
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, err
+	}
+	if len(artifact.ABI) == 0 || bytes.Equal(artifact.ABI, []byte("null")) {
+		err := errors.New("artifact has no ABI")
+		return nil, err
+	}
+	if len(name) == 0 {
+		name = artifact.ContractName
+	} else if len(artifact.ContractName) > 0 && artifact.ContractName != name {
+		err := fmt.Errorf("artifact contains contract %s, but %s is specified", artifact.ContractName, name)
+		return nil, err
+	}
+	var bytecode string
+	if len(artifact.Bytecode) > 0 {
+		if err := json.Unmarshal(artifact.Bytecode, &bytecode); err != nil {
+			var object artifactBytecodeJSON
+			if err := json.Unmarshal(artifact.Bytecode, &object); err != nil {
+				err = fmt.Errorf("artifact has unsupported bytecode format: %v", err)
+				return nil, err
+			}
+			bytecode = object.Object
+		}
+	}
+	bin, err := normalizeBytecode(bytecode)
+	if err != nil {
+		return nil, err
+	}
+	contract := &sol.Contract{
+		Name:       name,
+		SourcePath: path,
+		ABI:        []byte(artifact.ABI),
+		Bin:        bin,
+	}
+	return contract, nil
+}
+
+// normalizeBytecode validates the hex bytecode and returns it without 0x prefix,
+// the same way as solc outputs it.
+func normalizeBytecode(bin string) (string, error) {
+	bin = strings.TrimPrefix(strings.TrimSpace(bin), "0x")
+	if strings.Contains(bin, "__") {
+		err := errors.New("bytecode has unlinked library references")
+		return "", err
+	}
+	if _, err := hex.DecodeString(bin); err != nil {
+		err = fmt.Errorf("bytecode is not a valid hex string: %v", err)
+		return "", err
+	}
+	return bin, nil
+}
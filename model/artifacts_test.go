@@ -0,0 +1,79 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `[{"constant":true,"inputs":[],"name":"owner","outputs":[{"name":"","type":"address"}],"type":"function"}]`
+
+func TestLoadArtifactContract(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "playbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hardhat := filepath.Join(dir, "hardhat.json")
+	writeFixture(t, hardhat, `{"contractName":"Ownable","abi":`+testABI+`,"bytecode":"0x6080"}`)
+	contract, err := loadArtifactContract("", hardhat)
+	if assert.NoError(err) {
+		assert.Equal("Ownable", contract.Name)
+		assert.Equal("6080", contract.Bin)
+		assert.JSONEq(testABI, string(contract.ABI))
+	}
+	_, err = loadArtifactContract("Token", hardhat)
+	assert.Error(err)
+
+	foundry := filepath.Join(dir, "foundry.json")
+	writeFixture(t, foundry, `{"abi":`+testABI+`,"bytecode":{"object":"0x6080","linkReferences":{}}}`)
+	contract, err = loadArtifactContract("Ownable", foundry)
+	if assert.NoError(err) {
+		assert.Equal("Ownable", contract.Name)
+		assert.Equal("6080", contract.Bin)
+	}
+
+	linked := filepath.Join(dir, "linked.json")
+	writeFixture(t, linked, `{"abi":`+testABI+`,"bytecode":"0x73__$lib$__6080"}`)
+	_, err = loadArtifactContract("Ownable", linked)
+	assert.Error(err)
+}
+
+func TestLoadABIContract(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "playbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	abiPath := filepath.Join(dir, "Ownable.abi")
+	binPath := filepath.Join(dir, "Ownable.bin")
+	writeFixture(t, abiPath, testABI+"\n")
+	writeFixture(t, binPath, "6080\n")
+
+	contract, err := loadABIContract("Ownable", abiPath, "")
+	if assert.NoError(err) {
+		assert.Empty(contract.Bin)
+	}
+	contract, err = loadABIContract("Ownable", abiPath, binPath)
+	if assert.NoError(err) {
+		assert.Equal("6080", contract.Bin)
+	}
+	writeFixture(t, binPath, "0xkek")
+	_, err = loadABIContract("Ownable", abiPath, binPath)
+	assert.Error(err)
+}
+
+func writeFixture(t *testing.T, path, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -19,6 +19,9 @@ func (cmds CallCmds) Validate(ctx AppContext, spec *Spec) bool {
 			return false
 		}
 		spec.uniqueNames[name] = struct{}{}
+		if !cmd.Args.Validate(validateLog.WithField("command", name)) {
+			return false
+		}
 
 		if ctx.AppCommand() == name {
 			if !cmd.Validate(ctx, name, spec) {
@@ -36,7 +39,8 @@ func (cmds CallCmds) CallCmdSpec(name string) (*CallCmdSpec, bool) {
 
 type CallCmdSpec struct {
 	ParamSpec   `yaml:",inline"`
-	Description string `yaml:"desc"`
+	Description string   `yaml:"desc"`
+	Args        ArgSpecs `yaml:"args"`
 
 	Wallet string `yaml:"wallet"`
 	Method string `yaml:"method"`
@@ -87,6 +91,7 @@ func (spec *CallCmdSpec) MatchingWallets() []*WalletSpec {
 }
 
 func (spec *CallCmdSpec) CountArgsUsing(set map[int]struct{}) {
+	spec.Args.CountArgsUsing(set)
 	spec.ParamSpec.CountArgsUsing(set)
 }
 
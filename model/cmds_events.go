@@ -0,0 +1,239 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	log "github.com/sirupsen/logrus"
+)
+
+type EventCmds map[string]*EventCmdSpec
+
+func (cmds EventCmds) Validate(ctx AppContext, spec *Spec) bool {
+	validateLog := log.WithFields(log.Fields{
+		"section": "EventCmds",
+		"func":    "Validate",
+	})
+	for name, cmd := range cmds {
+		if _, ok := spec.uniqueNames[name]; ok {
+			validateLog.WithField("name", name).Errorln("cmd name is not unique")
+			return false
+		}
+		spec.uniqueNames[name] = struct{}{}
+		if !cmd.Args.Validate(validateLog.WithField("command", name)) {
+			return false
+		}
+
+		if ctx.AppCommand() == name {
+			if !cmd.Validate(ctx, name, spec) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (cmds EventCmds) EventCmdSpec(name string) (*EventCmdSpec, bool) {
+	spec, ok := cmds[name]
+	return spec, ok
+}
+
+// DefaultEventsChunkSize is the max number of blocks queried by a single eth_getLogs request.
+const DefaultEventsChunkSize uint64 = 5000
+
+type EventCmdSpec struct {
+	Description string   `yaml:"desc"`
+	Args        ArgSpecs `yaml:"args"`
+
+	Event     string                 `yaml:"event"`
+	Filter    map[string]interface{} `yaml:"filter"`
+	FromBlock string                 `yaml:"from_block"`
+	ToBlock   string                 `yaml:"to_block"`
+	ChunkSize uint64                 `yaml:"chunk_size"`
+
+	Instance *ContractInstanceSpec `yaml:"instance"`
+
+	filterValues map[string][]interface{} `yaml:"-"`
+	fromBlock    BlockNumber              `yaml:"-"`
+	toBlock      BlockNumber              `yaml:"-"`
+}
+
+func (spec *EventCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool {
+	validateLog := log.WithFields(log.Fields{
+		"section": "EventCommands",
+		"command": name,
+	})
+	if spec.Instance == nil {
+		validateLog.Errorln("no target contract instance specified")
+		return false
+	} else if len(spec.Instance.Name) == 0 {
+		validateLog.Errorln("the target contract spec name is not specified")
+		return false
+	}
+	contract, ok := root.Contracts.ContractSpec(spec.Instance.Name)
+	if !ok || contract == nil {
+		validateLog.Errorln("the target contract spec not found (name mismatch)")
+		return false
+	} else if len(contract.Instances) == 0 {
+		validateLog.Errorln("the target contract spec has no instances")
+		return false
+	}
+	instance, ok := contract.FindInstance(spec.Instance)
+	if !ok {
+		validateLog.Errorln("referenced contract instance is not found (address mismatch)")
+		return false
+	}
+	spec.Instance = instance
+	if len(spec.Event) == 0 {
+		validateLog.Errorln("no event name is specified")
+		return false
+	}
+	eventLog := validateLog.WithField("event", spec.Event)
+	event, ok := spec.Instance.BoundContract().ABI().Events[spec.Event]
+	if !ok {
+		eventLog.Errorln("event is not found in the contract ABI")
+		return false
+	} else if event.Anonymous {
+		eventLog.Errorln("anonymous events cannot be queried by name")
+		return false
+	}
+	if !spec.validateFilter(ctx, eventLog, root, event) {
+		return false
+	}
+	var err error
+	if spec.fromBlock, err = parseBlockNumber(ctx, spec.FromBlock, BlockEarliest); err != nil {
+		eventLog.WithError(err).Errorln("failed to parse from_block")
+		return false
+	}
+	if spec.toBlock, err = parseBlockNumber(ctx, spec.ToBlock, BlockLatest); err != nil {
+		eventLog.WithError(err).Errorln("failed to parse to_block")
+		return false
+	}
+	if spec.fromBlock != BlockLatest && spec.toBlock != BlockLatest && spec.fromBlock > spec.toBlock {
+		eventLog.Errorln("from_block must not be greater than to_block")
+		return false
+	}
+	if spec.ChunkSize == 0 {
+		spec.ChunkSize = DefaultEventsChunkSize
+	}
+	return true
+}
+
+func (spec *EventCmdSpec) validateFilter(ctx AppContext, validateLog *log.Entry, root *Spec, event abi.Event) bool {
+	inputs := make(map[string]abi.Argument, len(event.Inputs))
+	for _, input := range event.Inputs {
+		inputs[input.Name] = input
+	}
+	evaler := NewEvaler()
+	spec.filterValues = make(map[string][]interface{}, len(spec.Filter))
+	for argName, value := range spec.Filter {
+		argLog := validateLog.WithField("arg", argName)
+		input, ok := inputs[argName]
+		if !ok {
+			argLog.Errorln("event arg is not found in the contract ABI")
+			return false
+		} else if !input.Indexed {
+			argLog.Errorln("only indexed event args can be used in filter")
+			return false
+		}
+		switch input.Type.T {
+		case abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			argLog.Errorln("indexed args of composite types cannot be used in filter")
+			return false
+		}
+		// a list of values matches any of them
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if nillableStr(item) == walletPrefix+walletPrefix {
+				argLog.Errorln("wallet placeholder is not allowed in filter, use wallet name")
+				return false
+			}
+			v, err := parseCompositeElem(ctx, root, evaler, filterParamType(input.Type), item)
+			if err != nil {
+				argLog.WithError(err).Errorln("filter value parsing error, check type")
+				return false
+			} else if v == nil {
+				// unresolved until the arguments are provided
+				continue
+			}
+			values = append(values, v)
+		}
+		spec.filterValues[argName] = values
+	}
+	return true
+}
+
+// filterParamType maps the ABI type of an indexed arg to the param type used for parsing,
+// integers of any size are parsed as big ints and checked upon packing into topics.
+func filterParamType(typ abi.Type) ParamType {
+	switch typ.T {
+	case abi.IntTy:
+		return ParamTypeInt
+	case abi.UintTy:
+		return ParamTypeUInt
+	}
+	return ParamType(typ.String())
+}
+
+// FilterValues returns values of indexed args to filter events by, an event matches
+// if each of its filtered args matches any of the values. Values may contain wallet references.
+func (spec *EventCmdSpec) FilterValues() map[string][]interface{} {
+	return spec.filterValues
+}
+
+// BlockRange returns the block range to query events from, bounds may be BlockLatest.
+func (spec *EventCmdSpec) BlockRange() (from, to BlockNumber) {
+	return spec.fromBlock, spec.toBlock
+}
+
+func (spec *EventCmdSpec) CountArgsUsing(set map[int]struct{}) {
+	spec.Args.CountArgsUsing(set)
+	for _, value := range spec.Filter {
+		countCompositeArgsUsing(set, value)
+	}
+	countCompositeArgsUsing(set, spec.FromBlock)
+	countCompositeArgsUsing(set, spec.ToBlock)
+}
+
+func (spec *EventCmdSpec) ArgCount() int {
+	set := make(map[int]struct{})
+	spec.CountArgsUsing(set)
+	return len(set)
+}
+
+// BlockNumber is a block height, or BlockLatest for the latest block known to the node.
+type BlockNumber int64
+
+const (
+	BlockEarliest BlockNumber = 0
+	BlockLatest   BlockNumber = -1
+)
+
+func parseBlockNumber(ctx AppContext, str string, defaultBlock BlockNumber) (BlockNumber, error) {
+	str, err := substituteArgRefs(ctx, strings.TrimSpace(str))
+	if _, missing := err.(*MissingArg); missing {
+		// unresolved until the arguments are provided
+		return defaultBlock, nil
+	} else if err != nil {
+		return 0, err
+	}
+	switch str {
+	case "":
+		return defaultBlock, nil
+	case "earliest":
+		return BlockEarliest, nil
+	case "latest":
+		return BlockLatest, nil
+	}
+	n, err := strconv.ParseInt(str, 0, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a block number, earliest or latest, got %q", str)
+	}
+	return BlockNumber(n), nil
+}
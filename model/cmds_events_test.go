@@ -0,0 +1,44 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBlockNumber(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewAppContext(context.Background(), "transfers", []string{"transfers", "0x10"},
+		"genesis", "", "", nil, nil)
+	block, err := parseBlockNumber(ctx, "", BlockLatest)
+	if assert.NoError(err) {
+		assert.Equal(BlockLatest, block)
+	}
+	block, err = parseBlockNumber(ctx, "earliest", BlockLatest)
+	if assert.NoError(err) {
+		assert.Equal(BlockEarliest, block)
+	}
+	block, err = parseBlockNumber(ctx, "latest", BlockEarliest)
+	if assert.NoError(err) {
+		assert.Equal(BlockLatest, block)
+	}
+	block, err = parseBlockNumber(ctx, "1200", BlockEarliest)
+	if assert.NoError(err) {
+		assert.Equal(BlockNumber(1200), block)
+	}
+	block, err = parseBlockNumber(ctx, "$1", BlockEarliest)
+	if assert.NoError(err) {
+		assert.Equal(BlockNumber(16), block)
+	}
+	// not provided yet
+	block, err = parseBlockNumber(ctx, "$2", BlockEarliest)
+	if assert.NoError(err) {
+		assert.Equal(BlockEarliest, block)
+	}
+	_, err = parseBlockNumber(ctx, "-1", BlockEarliest)
+	assert.Error(err)
+	_, err = parseBlockNumber(ctx, "pending", BlockEarliest)
+	assert.Error(err)
+}
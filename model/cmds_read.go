@@ -2,7 +2,6 @@ package model
 
 import (
 	"regexp"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -20,6 +19,9 @@ func (cmds ViewCmds) Validate(ctx AppContext, spec *Spec) bool {
 			return false
 		}
 		spec.uniqueNames[name] = struct{}{}
+		if !cmd.Args.Validate(validateLog.WithField("command", name)) {
+			return false
+		}
 
 		if ctx.AppCommand() == name {
 			if !cmd.Validate(ctx, name, spec) {
@@ -37,7 +39,8 @@ func (cmds ViewCmds) ViewCmdSpec(name string) (*ViewCmdSpec, bool) {
 
 type ViewCmdSpec struct {
 	ParamSpec   `yaml:",inline"`
-	Description string `yaml:"desc"`
+	Description string   `yaml:"desc"`
+	Args        ArgSpecs `yaml:"args"`
 
 	Wallet string `yaml:"wallet"`
 	Method string `yaml:"method"`
@@ -90,23 +93,12 @@ func (spec *ViewCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool
 		validateLog.Errorln("the target contract spec has no instances")
 		return false
 	}
-	address := strings.ToLower(spec.Instance.Address)
-	if len(address) == 0 {
-		spec.Instance = contract.Instances[0]
-	} else {
-		var found bool
-		for _, instance := range contract.Instances {
-			if strings.ToLower(instance.Address) == address {
-				found = true
-				spec.Instance = instance
-				break
-			}
-		}
-		if !found {
-			validateLog.Errorln("referenced contract instance is not found (address mismatch)")
-			return false
-		}
+	instance, ok := contract.FindInstance(spec.Instance)
+	if !ok {
+		validateLog.Errorln("referenced contract instance is not found (address mismatch)")
+		return false
 	}
+	spec.Instance = instance
 	if len(spec.Method) == 0 {
 		validateLog.Errorln("no method name is specified")
 		return false
@@ -114,6 +106,14 @@ func (spec *ViewCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool
 	if !spec.ParamSpec.Validate(ctx, name, root) {
 		return false
 	}
+	methodLog := validateLog.WithField("method", spec.Method)
+	method, ok := spec.Instance.BoundContract().ABI().Methods[spec.Method]
+	if !ok {
+		methodLog.Errorln("method is not found in the contract ABI")
+		return false
+	} else if !spec.ParamSpec.ValidateABI(methodLog, method.Inputs) {
+		return false
+	}
 	return true
 }
 
@@ -122,6 +122,7 @@ func (spec *ViewCmdSpec) MatchingWallets() []*WalletSpec {
 }
 
 func (spec *ViewCmdSpec) CountArgsUsing(set map[int]struct{}) {
+	spec.Args.CountArgsUsing(set)
 	spec.ParamSpec.CountArgsUsing(set)
 }
 
@@ -1,8 +1,9 @@
 package model
 
 import (
+	"math/big"
 	"regexp"
-	"strings"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -20,6 +21,9 @@ func (cmds WriteCmds) Validate(ctx AppContext, spec *Spec) bool {
 			return false
 		}
 		spec.uniqueNames[name] = struct{}{}
+		if !cmd.Args.Validate(validateLog.WithField("command", name)) {
+			return false
+		}
 
 		if ctx.AppCommand() == name {
 			if !cmd.Validate(ctx, name, spec) {
@@ -37,7 +41,8 @@ func (cmds WriteCmds) WriteCmdSpec(name string) (*WriteCmdSpec, bool) {
 
 type WriteCmdSpec struct {
 	ParamSpec   `yaml:",inline"`
-	Description string `yaml:"desc"`
+	Description string   `yaml:"desc"`
+	Args        ArgSpecs `yaml:"args"`
 
 	Wallet string `yaml:"wallet"`
 	Sticky string `yaml:"sticky"`
@@ -45,10 +50,17 @@ type WriteCmdSpec struct {
 	Value  Valuer `yaml:"value"`
 	Method string `yaml:"method"`
 
+	// override the dynamic fees of CONFIG
+	MaxFeePerGas         string `yaml:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `yaml:"maxPriorityFeePerGas"`
+	// overrides gasLimit of CONFIG, it's the exact gas of transactions signed offline
+	GasLimit string `yaml:"gasLimit"`
+
 	Instance *ContractInstanceSpec `yaml:"instance"`
 
-	walletRx *regexp.Regexp `yaml:"-"`
-	matching *WalletSpec    `yaml:"-"`
+	walletRx   *regexp.Regexp `yaml:"-"`
+	matching   *WalletSpec    `yaml:"-"`
+	toResolved bool           `yaml:"-"`
 }
 
 func (spec *WriteCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool {
@@ -103,24 +115,12 @@ func (spec *WriteCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool
 			validateLog.Errorln("the recipient contract spec has no instances")
 			return false
 		}
-		address := strings.ToLower(spec.Instance.Address)
-		if len(address) == 0 {
-			spec.Instance = contract.Instances[0]
-		} else {
-			var found bool
-			for _, instance := range contract.Instances {
-				if strings.ToLower(instance.Address) == address {
-					found = true
-					spec.Instance = instance
-					break
-				}
-			}
-			if !found {
-				validateLog.Errorln("referenced contract instance is not found (address mismatch)")
-				return false
-			}
+		instance, ok := contract.FindInstance(spec.Instance)
+		if !ok {
+			validateLog.Errorln("referenced contract instance is not found (address mismatch)")
+			return false
 		}
-		// TODO: check ABI
+		spec.Instance = instance
 	} else if spec.Instance != nil {
 		validateLog.Errorln("contract instance must not be specified while using recipient 'to' address")
 		return false
@@ -129,7 +129,8 @@ func (spec *WriteCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool
 			validateLog.Errorln("wallet reference is not allowed in 'to' field, must be name")
 			return false
 		}
-		if spec.To != ZeroAddress {
+		// the command may be validated again, within targets
+		if spec.To != ZeroAddress && !spec.toResolved {
 			if wallet, ok := root.Wallets.WalletSpec(spec.To); !ok {
 				validateLog.Errorln("recipient 'to' wallet name is not found")
 				return false
@@ -138,20 +139,80 @@ func (spec *WriteCmdSpec) Validate(ctx AppContext, name string, root *Spec) bool
 				return false
 			} else {
 				spec.To = wallet.Address
+				spec.toResolved = true
 			}
 		}
 	}
+	if !validateFees(validateLog, spec.MaxFeePerGas, spec.MaxPriorityFeePerGas) {
+		return false
+	}
+	if len(spec.GasLimit) > 0 {
+		if _, err := strconv.ParseUint(spec.GasLimit, 10, 64); err != nil {
+			validateLog.WithError(err).Errorln("failed to parse gasLimit")
+			return false
+		}
+	}
 	if !spec.ParamSpec.Validate(ctx, name, root) {
 		return false
 	}
+	if spec.Instance != nil && !spec.validateABI(validateLog) {
+		return false
+	}
 	return true
 }
 
+func (spec *WriteCmdSpec) validateABI(validateLog *log.Entry) bool {
+	contractABI := spec.Instance.BoundContract().ABI()
+	if len(spec.Method) == 0 {
+		constructorLog := validateLog.WithField("method", "constructor")
+		if !spec.Instance.IsDeployed() && len(spec.Instance.BoundContract().Source().Bin) == 0 {
+			constructorLog.Errorln("contract has no bytecode to deploy, only ABI is known")
+			return false
+		}
+		return spec.ParamSpec.ValidateABI(constructorLog, contractABI.Constructor.Inputs)
+	}
+	methodLog := validateLog.WithField("method", spec.Method)
+	method, ok := contractABI.Methods[spec.Method]
+	if !ok {
+		methodLog.Errorln("method is not found in the contract ABI")
+		return false
+	} else if method.Const {
+		methodLog.Warningln("method is constant, consider using a VIEW command")
+	}
+	return spec.ParamSpec.ValidateABI(methodLog, method.Inputs)
+}
+
+// MaxFeePerGasInt returns the max fee per gas of the command, or the one of config.
+func (spec *WriteCmdSpec) MaxFeePerGasInt(config *ConfigSpec) *big.Int {
+	if fee := parseFee(spec.MaxFeePerGas); fee != nil {
+		return fee
+	}
+	return config.MaxFeePerGasInt()
+}
+
+// MaxPriorityFeePerGasInt returns the max priority fee per gas of the command, or the one of config.
+func (spec *WriteCmdSpec) MaxPriorityFeePerGasInt(config *ConfigSpec) *big.Int {
+	if fee := parseFee(spec.MaxPriorityFeePerGas); fee != nil {
+		return fee
+	}
+	return config.MaxPriorityFeePerGasInt()
+}
+
+// GasLimitInt returns the gas limit of the command, or the one of config.
+func (spec *WriteCmdSpec) GasLimitInt(config *ConfigSpec) uint64 {
+	if gasLimit, err := strconv.ParseUint(spec.GasLimit, 10, 64); err == nil {
+		return gasLimit
+	}
+	gasLimit, _ := config.GasLimitInt()
+	return gasLimit
+}
+
 func (spec *WriteCmdSpec) MatchingWallet() *WalletSpec {
 	return spec.matching
 }
 
 func (spec *WriteCmdSpec) CountArgsUsing(set map[int]struct{}) {
+	spec.Args.CountArgsUsing(set)
 	spec.ParamSpec.CountArgsUsing(set)
 	spec.Value.CountArgsUsing(set)
 }
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// Condition is a boolean expression of a target step, the step is skipped if it's false.
+// Terms are separated by spaces, the references are resolved before evaluation: wallet balances (@alice.balance),
+// args ($1 or $name) and step results (#step.field), e.g. "#total-supply.result[0] == 0 && @bob.balance < 1e18".
+type Condition string
+
+// BalanceFunc returns the current balance of the wallet.
+type BalanceFunc func(wallet *WalletSpec) (*big.Int, error)
+
+func (cond Condition) Validate(ctx AppContext, root *Spec, validateLog *log.Entry) bool {
+	condLog := validateLog.WithField("when", string(cond))
+	for _, part := range strings.Fields(string(cond)) {
+		switch {
+		case isWalletRef(part):
+			ref, err := newWalletFieldReference(root, part)
+			if err != nil {
+				condLog.WithError(err).Errorln("failed to parse wallet reference")
+				return false
+			} else if ref.FieldName != WalletSpecBalanceField {
+				condLog.WithField("field", ref.FieldName).Errorln("only wallet balances can be used in conditions")
+				return false
+			}
+		case isArgRef(part):
+			if _, err := newArgReference(ctx, part); err != nil {
+				condLog.WithError(err).Errorln("failed to parse arg reference")
+				return false
+			}
+		case isStepRef(part):
+			if _, err := newStepReference(part, ""); err != nil {
+				condLog.WithError(err).Errorln("failed to parse step reference")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// StepReferences returns references to step results used in the condition.
+func (cond Condition) StepReferences() []*StepReference {
+	var refs []*StepReference
+	for _, part := range strings.Fields(string(cond)) {
+		if isStepRef(part) {
+			if ref, err := newStepReference(part, ""); err == nil {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// Eval resolves the references and evaluates the condition.
+func (cond Condition) Eval(ctx AppContext, root *Spec, balanceOf BalanceFunc) (bool, error) {
+	parts := strings.Fields(string(cond))
+	for i, part := range parts {
+		switch {
+		case isWalletRef(part):
+			ref, err := newWalletFieldReference(root, part)
+			if err != nil {
+				return false, err
+			}
+			wallet, _ := root.Wallets.WalletSpec(ref.WalletName)
+			balance, err := balanceOf(wallet)
+			if err != nil {
+				return false, err
+			}
+			parts[i] = balance.String()
+		case isArgRef(part):
+			ref, err := newArgReference(ctx, part)
+			if err != nil {
+				return false, err
+			} else if ref.ArgID < 0 {
+				err := errors.New("insufficient arguments provided")
+				return false, err
+			}
+			parts[i] = conditionTerm(ctx.AppCommandArgs()[ref.ArgID])
+		case isStepRef(part):
+			ref, err := newStepReference(part, "")
+			if err != nil {
+				return false, err
+			}
+			v, err := ctx.ResolveStepReference(ref)
+			if err != nil {
+				return false, err
+			}
+			parts[i] = conditionTerm(v)
+		}
+	}
+	result, err := NewEvaler().Run(strings.Join(parts, " "), ExprTypeBool)
+	if err != nil {
+		err = fmt.Errorf("failed to evaluate condition %q: %v", cond, err)
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// conditionTerm formats the value as a term of expression, addresses and hashes are hex integers.
+func conditionTerm(v interface{}) string {
+	switch vv := v.(type) {
+	case *big.Int:
+		return vv.String()
+	case bool:
+		return strconv.FormatBool(vv)
+	case common.Address:
+		return strings.ToLower(vv.Hex())
+	case common.Hash:
+		return vv.Hex()
+	case string:
+		if _, ok := new(big.Int).SetString(vv, 0); ok {
+			return vv
+		} else if _, err := strconv.ParseFloat(vv, 64); err == nil {
+			return vv
+		}
+		return strconv.Quote(vv)
+	default:
+		return fmt.Sprintf("%v", vv)
+	}
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStepResults map[string]interface{}
+
+func (r testStepResults) ResolveStep(ref *StepReference) (interface{}, error) {
+	v, ok := r[ref.Step]
+	if !ok {
+		return nil, fmt.Errorf("step %s has no results yet", ref.Step)
+	}
+	return v, nil
+}
+
+func TestCondition(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewAppContext(context.Background(), "fund", []string{"fund", "100"},
+		"genesis", "", "", nil, nil)
+	ctx = ctx.WithStepResults(testStepResults{
+		"total-supply": big.NewInt(0),
+		"symbol":       "PTO",
+	})
+	root := &Spec{
+		Wallets: Wallets{
+			"bob": &WalletSpec{
+				Address: "0xa480763627636ff8b8ce97d0d6608e99fddb1062",
+			},
+		},
+	}
+	balanceOf := func(wallet *WalletSpec) (*big.Int, error) {
+		return big.NewInt(50), nil
+	}
+	validateLog := log.WithField("test", "TestCondition")
+
+	cond := Condition("#total-supply.result == 0 && @bob.balance < $1")
+	assert.True(cond.Validate(ctx, root, validateLog))
+	assert.Len(cond.StepReferences(), 1)
+	ok, err := cond.Eval(ctx, root, balanceOf)
+	assert.NoError(err)
+	assert.True(ok)
+
+	ok, err = Condition(`#symbol.result == "PTO" && @bob.balance > 50`).Eval(ctx, root, balanceOf)
+	assert.NoError(err)
+	assert.False(ok)
+
+	_, err = Condition("@bob.balance + 1").Eval(ctx, root, balanceOf)
+	assert.Error(err)
+	_, err = Condition("#missing.result == 0").Eval(ctx, root, balanceOf)
+	assert.Error(err)
+	assert.False(Condition("@bob.address == 0").Validate(ctx, root, validateLog))
+	assert.False(Condition("#symbol.receipt == 0").Validate(ctx, root, validateLog))
+}
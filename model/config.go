@@ -14,6 +14,18 @@ type ConfigSpec struct {
 	GasLimit     string `yaml:"gasLimit"`
 	ChainID      string `yaml:"chainID"`
 	AwaitTimeout string `yaml:"awaitTimeout"`
+	PollInterval string `yaml:"pollInterval"`
+	// dynamic fees (EIP-1559) are estimated if not set,
+	// gasPrice is used if the node reports no base fee.
+	MaxFeePerGas         string `yaml:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `yaml:"maxPriorityFeePerGas"`
+	// transactions not mined within gasBumpInterval are resubmitted with fees bumped by gasBumpPercent,
+	// up to gasBumpMaxFee per gas. Disabled if the interval is not set.
+	GasBumpInterval string `yaml:"gasBumpInterval"`
+	GasBumpPercent  string `yaml:"gasBumpPercent"`
+	GasBumpMaxFee   string `yaml:"gasBumpMaxFee"`
+	// transactions are awaited until their block is that deep, and still canonical
+	Confirmations string `yaml:"confirmations"`
 
 	SpecDir string `yaml:"-"`
 }
@@ -26,6 +38,12 @@ var DefaultConfigSpec = &ConfigSpec{
 	// hard limit, real limit is estimated
 	GasLimit:     "10000000",
 	AwaitTimeout: "10m",
+	// used when subscriptions are not available
+	PollInterval: "5s",
+	// nodes require at least 10% to replace a transaction
+	GasBumpPercent: "15",
+	// the block of transaction only
+	Confirmations: "1",
 }
 
 func (spec *ConfigSpec) Validate() bool {
@@ -60,6 +78,45 @@ func (spec *ConfigSpec) Validate() bool {
 	} else {
 		spec.AwaitTimeout = DefaultConfigSpec.AwaitTimeout
 	}
+	if len(spec.PollInterval) > 0 {
+		if _, err := spec.PollIntervalDuration(); err != nil {
+			validateLog.Errorln("failed to parse pollInterval")
+		}
+	} else {
+		spec.PollInterval = DefaultConfigSpec.PollInterval
+	}
+	if !validateFees(validateLog, spec.MaxFeePerGas, spec.MaxPriorityFeePerGas) {
+		return false
+	}
+	if len(spec.GasBumpInterval) > 0 {
+		if interval, err := spec.GasBumpIntervalDuration(); err != nil || interval <= 0 {
+			validateLog.Errorln("failed to parse gasBumpInterval")
+			return false
+		}
+	}
+	if len(spec.GasBumpPercent) > 0 {
+		if percent, err := strconv.ParseUint(spec.GasBumpPercent, 10, 64); err != nil {
+			validateLog.Errorln("failed to parse gasBumpPercent")
+			return false
+		} else if percent < 10 {
+			validateLog.Errorln("gasBumpPercent must be at least 10, to replace a transaction")
+			return false
+		}
+	} else {
+		spec.GasBumpPercent = DefaultConfigSpec.GasBumpPercent
+	}
+	if len(spec.GasBumpMaxFee) > 0 && parseFee(spec.GasBumpMaxFee) == nil {
+		validateLog.Errorln("failed to parse gasBumpMaxFee")
+		return false
+	}
+	if len(spec.Confirmations) > 0 {
+		if _, err := strconv.ParseUint(spec.Confirmations, 10, 64); err != nil {
+			validateLog.Errorln("failed to parse confirmations")
+			return false
+		}
+	} else {
+		spec.Confirmations = DefaultConfigSpec.Confirmations
+	}
 	return true
 }
 
@@ -82,3 +139,76 @@ func (spec *ConfigSpec) ChainIDInt() (*big.Int, bool) {
 func (spec *ConfigSpec) AwaitTimeoutDuration() (time.Duration, error) {
 	return time.ParseDuration(spec.AwaitTimeout)
 }
+
+func (spec *ConfigSpec) PollIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(spec.PollInterval)
+}
+
+// GasBumpIntervalDuration returns the interval of fee bumps of pending transactions, zero if disabled.
+func (spec *ConfigSpec) GasBumpIntervalDuration() (time.Duration, error) {
+	if len(spec.GasBumpInterval) == 0 {
+		return 0, nil
+	}
+	return time.ParseDuration(spec.GasBumpInterval)
+}
+
+func (spec *ConfigSpec) GasBumpPercentInt() int64 {
+	percent, err := strconv.ParseInt(spec.GasBumpPercent, 10, 64)
+	if err != nil {
+		percent, _ = strconv.ParseInt(DefaultConfigSpec.GasBumpPercent, 10, 64)
+	}
+	return percent
+}
+
+// ConfirmationsInt returns the number of blocks, including the block of transaction, to await.
+func (spec *ConfigSpec) ConfirmationsInt() uint64 {
+	confirmations, err := strconv.ParseUint(spec.Confirmations, 10, 64)
+	if err != nil {
+		confirmations, _ = strconv.ParseUint(DefaultConfigSpec.Confirmations, 10, 64)
+	}
+	return confirmations
+}
+
+// GasBumpMaxFeeInt returns the limit of bumped fees per gas, or nil if there is no limit.
+func (spec *ConfigSpec) GasBumpMaxFeeInt() *big.Int {
+	return parseFee(spec.GasBumpMaxFee)
+}
+
+// MaxFeePerGasInt returns the max fee per gas, or nil if it should be estimated.
+func (spec *ConfigSpec) MaxFeePerGasInt() *big.Int {
+	return parseFee(spec.MaxFeePerGas)
+}
+
+// MaxPriorityFeePerGasInt returns the max priority fee per gas, or nil if it should be estimated.
+func (spec *ConfigSpec) MaxPriorityFeePerGasInt() *big.Int {
+	return parseFee(spec.MaxPriorityFeePerGas)
+}
+
+func parseFee(fee string) *big.Int {
+	if len(fee) == 0 {
+		return nil
+	}
+	v, ok := big.NewInt(0).SetString(fee, 10)
+	if !ok || v.Sign() < 0 {
+		return nil
+	}
+	return v
+}
+
+func validateFees(validateLog *log.Entry, maxFee, maxPriorityFee string) bool {
+	if len(maxFee) > 0 && parseFee(maxFee) == nil {
+		validateLog.Errorln("failed to parse maxFeePerGas")
+		return false
+	}
+	if len(maxPriorityFee) > 0 && parseFee(maxPriorityFee) == nil {
+		validateLog.Errorln("failed to parse maxPriorityFeePerGas")
+		return false
+	}
+	if len(maxFee) > 0 && len(maxPriorityFee) > 0 {
+		if parseFee(maxFee).Cmp(parseFee(maxPriorityFee)) < 0 {
+			validateLog.Errorln("maxPriorityFeePerGas must not be greater than maxFeePerGas")
+			return false
+		}
+	}
+	return true
+}
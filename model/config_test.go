@@ -0,0 +1,60 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFees(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &ConfigSpec{
+		MaxFeePerGas: "50000000000",
+	}
+	assert.True(config.Validate())
+	assert.Equal(big.NewInt(50000000000), config.MaxFeePerGasInt())
+	assert.Nil(config.MaxPriorityFeePerGasInt())
+
+	cmd := &WriteCmdSpec{
+		MaxPriorityFeePerGas: "2000000000",
+	}
+	assert.Equal(big.NewInt(50000000000), cmd.MaxFeePerGasInt(config))
+	assert.Equal(big.NewInt(2000000000), cmd.MaxPriorityFeePerGasInt(config))
+	cmd.MaxFeePerGas = "40000000000"
+	assert.Equal(big.NewInt(40000000000), cmd.MaxFeePerGasInt(config))
+
+	validateLog := log.WithField("test", "TestConfigFees")
+	assert.True(validateFees(validateLog, "", ""))
+	assert.True(validateFees(validateLog, "2", "2"))
+	assert.False(validateFees(validateLog, "1", "2"))
+	assert.False(validateFees(validateLog, "30 gwei", ""))
+	assert.False(validateFees(validateLog, "", "-1"))
+}
+
+func TestConfigGasBump(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &ConfigSpec{}
+	assert.True(config.Validate())
+	interval, err := config.GasBumpIntervalDuration()
+	assert.NoError(err)
+	assert.Zero(interval)
+	assert.EqualValues(15, config.GasBumpPercentInt())
+	assert.Nil(config.GasBumpMaxFeeInt())
+
+	config = &ConfigSpec{
+		GasBumpInterval: "2m",
+		GasBumpPercent:  "25",
+		GasBumpMaxFee:   "200000000000",
+	}
+	assert.True(config.Validate())
+	assert.EqualValues(25, config.GasBumpPercentInt())
+	assert.Equal(big.NewInt(200000000000), config.GasBumpMaxFeeInt())
+
+	assert.False((&ConfigSpec{GasBumpInterval: "soon"}).Validate())
+	assert.False((&ConfigSpec{GasBumpPercent: "5"}).Validate())
+	assert.False((&ConfigSpec{GasBumpMaxFee: "1 gwei"}).Validate())
+}
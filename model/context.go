@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"time"
 
 	"github.com/AtlantPlatform/ethfw"
 	"github.com/AtlantPlatform/ethfw/sol"
@@ -12,12 +13,13 @@ type AppContext struct {
 }
 
 func NewAppContext(ctx context.Context, appCommand string, appCommandArgs []string, nodeGroup string,
-	specDir string, solcCompiler sol.Compiler, keycache ethfw.KeyCache) AppContext {
+	specDir, statePath string, solcCompiler sol.Compiler, keycache ethfw.KeyCache) AppContext {
 	ctx = context.WithValue(ctx, "prefix", "playbook")
 	ctx = context.WithValue(ctx, "cmd", appCommand)
 	ctx = context.WithValue(ctx, "args", appCommandArgs)
 	ctx = context.WithValue(ctx, "group", nodeGroup)
 	ctx = context.WithValue(ctx, "specdir", specDir)
+	ctx = context.WithValue(ctx, "state", statePath)
 	ctx = context.WithValue(ctx, "keycache", keycache)
 	ctx = context.WithValue(ctx, "sol", solcCompiler)
 	return AppContext{ctx}
@@ -39,10 +41,53 @@ func (ctx AppContext) SpecDir() string {
 	return ctx.Value("specdir").(string)
 }
 
+func (ctx AppContext) StatePath() string {
+	return ctx.Value("state").(string)
+}
+
 func (ctx AppContext) SolcCompiler() sol.Compiler {
 	return ctx.Value("sol").(sol.Compiler)
 }
 
+// WithTimeout returns a copy of the context that is done after the timeout, keeping the values of the context.
+func (ctx AppContext) WithTimeout(timeout time.Duration) (AppContext, context.CancelFunc) {
+	timeoutCtx, cancelFn := context.WithTimeout(ctx.Context, timeout)
+	return AppContext{timeoutCtx}, cancelFn
+}
+
+// WithDryRun returns a context where WRITE commands are simulated instead of being sent.
+func (ctx AppContext) WithDryRun(dryRun bool) AppContext {
+	return AppContext{context.WithValue(ctx.Context, "dryrun", dryRun)}
+}
+
+func (ctx AppContext) DryRun() bool {
+	dryRun, _ := ctx.Value("dryrun").(bool)
+	return dryRun
+}
+
+// WithSignOnly returns a context where WRITE commands are signed offline with pre-assigned nonces,
+// the signed transactions are saved into the file at path instead of being sent.
+func (ctx AppContext) WithSignOnly(path string, nonces map[string]uint64) AppContext {
+	ctx.Context = context.WithValue(ctx.Context, "signonly", path)
+	return AppContext{context.WithValue(ctx.Context, "nonces", nonces)}
+}
+
+// SignOnly returns the path of file to save the signed transactions into.
+func (ctx AppContext) SignOnly() string {
+	path, _ := ctx.Value("signonly").(string)
+	return path
+}
+
+func (ctx AppContext) IsSignOnly() bool {
+	return len(ctx.SignOnly()) > 0
+}
+
+// SignNonces returns the starting nonces of wallets by name, in sign-only mode.
+func (ctx AppContext) SignNonces() map[string]uint64 {
+	nonces, _ := ctx.Value("nonces").(map[string]uint64)
+	return nonces
+}
+
 func (ctx AppContext) KeyCache() ethfw.KeyCache {
 	return ctx.Value("keycache").(ethfw.KeyCache)
 }
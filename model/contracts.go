@@ -23,19 +23,78 @@ func (contracts Contracts) UseSolc() bool {
 }
 
 func (contracts Contracts) Validate(ctx AppContext, spec *Spec) bool {
+	state, err := LoadDeploymentState(ctx.StatePath())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"section": "Contracts",
+			"state":   ctx.StatePath(),
+		}).WithError(err).Errorln("failed to load deployment state file")
+		return false
+	}
+	spec.state = state
 	for name, contract := range contracts {
 		if !contract.Validate(ctx, name) {
 			return false
 		}
-		for _, instance := range contract.Instances {
+		ids := make(map[string]struct{}, len(contract.Instances))
+		for offset, instance := range contract.Instances {
 			if !instance.Validate(ctx, name, contract.src) {
 				return false
 			}
+			instance.contract = name
+			instance.offset = offset
+			if len(instance.ID) > 0 {
+				if _, ok := ids[instance.ID]; ok {
+					log.WithFields(log.Fields{
+						"section":  "ContractInstances",
+						"contract": name,
+						"id":       instance.ID,
+					}).Errorln("instance id is not unique")
+					return false
+				}
+				ids[instance.ID] = struct{}{}
+			}
 		}
+		contract.loadDeployments(name, state)
 	}
 	return true
 }
 
+// loadDeployments sets the addresses of the instances deployed before, only the mined deployments are loaded.
+// Instances without ids are keyed by their offsets, those are loaded only if there is a single instance
+// to deploy, otherwise reordering the instances would bind them to each other's addresses.
+func (spec *ContractSpec) loadDeployments(name string, state *DeploymentState) {
+	var byOffset int
+	for _, instance := range spec.Instances {
+		if !instance.IsDeployed() && len(instance.ID) == 0 {
+			byOffset++
+		}
+	}
+	for offset, instance := range spec.Instances {
+		if instance.IsDeployed() {
+			continue
+		}
+		deployed, ok := state.Instance(name, instance.ID, offset)
+		if !ok {
+			continue
+		}
+		stateLog := log.WithFields(log.Fields{
+			"section":  "ContractInstances",
+			"contract": name,
+			"address":  deployed.Address,
+		})
+		if deployed.Block == 0 {
+			stateLog.Warningln("deployment has not been mined, the instance is not loaded from deployment state")
+			continue
+		} else if len(instance.ID) == 0 && byOffset > 1 {
+			stateLog.Warningln("instances to deploy have no ids, the instance is not loaded from deployment state")
+			continue
+		}
+		instance.Address = deployed.Address
+		stateLog.Debugln("loaded instance address from deployment state")
+	}
+}
+
 func (contracts Contracts) ContractSpec(name string) (*ContractSpec, bool) {
 	spec, ok := contracts[name]
 	return spec, ok
@@ -58,22 +117,63 @@ type ContractSpec struct {
 	SolPath       string                  `yaml:"sol"`
 	Optimize      int                     `yaml:"optimize"`
 	SolAllowPaths []string                `yaml:"allow"`
+	ABIPath       string                  `yaml:"abi"`
+	BinPath       string                  `yaml:"bin"`
+	ArtifactPath  string                  `yaml:"artifact"`
 	Instances     []*ContractInstanceSpec `yaml:"instances"`
 
 	src *sol.Contract `yaml:"-"`
 }
 
+// FindInstance returns the contract instance referenced by a command, by the address specified,
+// or the first one if there is no address. A command validated before references the instance itself,
+// whose address may have been loaded from the deployment state or set upon deployment since.
+func (spec *ContractSpec) FindInstance(ref *ContractInstanceSpec) (*ContractInstanceSpec, bool) {
+	for _, instance := range spec.Instances {
+		if instance == ref {
+			return instance, true
+		}
+	}
+	address := strings.ToLower(ref.Address)
+	if len(address) == 0 {
+		return spec.Instances[0], true
+	}
+	for _, instance := range spec.Instances {
+		if instance.SpecAddress() == address {
+			return instance, true
+		}
+	}
+	return nil, false
+}
+
 func (spec *ContractSpec) Validate(ctx AppContext, name string) bool {
 	validateLog := log.WithFields(log.Fields{
 		"section":  "Contracts",
 		"contract": name,
 	})
-	if len(spec.Name) == 0 {
-		validateLog.Errorln("the root contract name must be specified")
+	var sources int
+	for _, path := range []string{spec.SolPath, spec.ABIPath, spec.ArtifactPath} {
+		if len(path) > 0 {
+			sources++
+		}
+	}
+	if sources == 0 {
+		validateLog.Errorln("contract spec must have the path to .sol file, ABI file or JSON artifact")
+		return false
+	} else if sources > 1 {
+		validateLog.Errorln("contract spec must have only one of sol, abi or artifact paths")
+		return false
+	} else if len(spec.BinPath) > 0 && len(spec.ABIPath) == 0 {
+		validateLog.Errorln("bytecode file must be accompanied by the ABI file")
 		return false
 	}
-	if len(spec.SolPath) == 0 {
-		validateLog.Errorln("contract spec must have the path to .sol file")
+	if len(spec.ABIPath) > 0 {
+		return spec.validateABI(ctx, validateLog)
+	} else if len(spec.ArtifactPath) > 0 {
+		return spec.validateArtifact(ctx, validateLog)
+	}
+	if len(spec.Name) == 0 {
+		validateLog.Errorln("the root contract name must be specified")
 		return false
 	}
 	if !filepath.IsAbs(spec.SolPath) {
@@ -106,12 +206,73 @@ func (spec *ContractSpec) Validate(ctx AppContext, name string) bool {
 	return true
 }
 
+func (spec *ContractSpec) validateABI(ctx AppContext, validateLog *log.Entry) bool {
+	if len(spec.Name) == 0 {
+		validateLog.Errorln("the root contract name must be specified")
+		return false
+	}
+	abiPath := specRelativePath(ctx, spec.ABIPath)
+	if !isFile(abiPath) {
+		validateLog.WithField("abi", spec.ABIPath).Errorln("ABI file is not found or cannot be read")
+		return false
+	}
+	var binPath string
+	if len(spec.BinPath) > 0 {
+		binPath = specRelativePath(ctx, spec.BinPath)
+		if !isFile(binPath) {
+			validateLog.WithField("bin", spec.BinPath).Errorln("bytecode file is not found or cannot be read")
+			return false
+		}
+	}
+	src, err := loadABIContract(spec.Name, abiPath, binPath)
+	if err != nil {
+		validateLog.WithError(err).Errorln("failed to load contract ABI and bytecode")
+		return false
+	}
+	spec.src = src
+	return true
+}
+
+func (spec *ContractSpec) validateArtifact(ctx AppContext, validateLog *log.Entry) bool {
+	artifactPath := specRelativePath(ctx, spec.ArtifactPath)
+	if !isFile(artifactPath) {
+		validateLog.WithField("artifact", spec.ArtifactPath).Errorln("artifact file is not found or cannot be read")
+		return false
+	}
+	src, err := loadArtifactContract(spec.Name, artifactPath)
+	if err != nil {
+		validateLog.WithError(err).Errorln("failed to load contract artifact")
+		return false
+	}
+	spec.Name = src.Name
+	spec.src = src
+	return true
+}
+
+// Source returns the compiled or loaded contract, available after validation.
+func (spec *ContractSpec) Source() *sol.Contract {
+	return spec.src
+}
+
+func specRelativePath(ctx AppContext, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(ctx.SpecDir(), path)
+}
+
 type ContractInstanceSpec struct {
 	Name    string `yaml:"contract"`
 	Address string `yaml:"address"`
+	// ID keys the instance in the deployment state, so the instances can be reordered
+	ID string `yaml:"id"`
 
 	binding     *ethfw.BoundContract `yaml:"-"`
 	tokenSymbol string               `yaml:"-"`
+	specAddress string               `yaml:"-"`
+	contract    string               `yaml:"-"`
+	offset      int                  `yaml:"-"`
 }
 
 func (spec *ContractInstanceSpec) Validate(ctx AppContext, name string, src *sol.Contract) bool {
@@ -134,9 +295,26 @@ func (spec *ContractInstanceSpec) Validate(ctx AppContext, name string, src *sol
 		return false
 	}
 	spec.binding = binding
+	spec.specAddress = strings.ToLower(spec.Address)
 	return true
 }
 
+// SpecAddress returns the instance address as it has been specified in the spec,
+// before any deployment state has been applied.
+func (spec *ContractInstanceSpec) SpecAddress() string {
+	return spec.specAddress
+}
+
+// ContractName returns the name of contract spec this instance belongs to.
+func (spec *ContractInstanceSpec) ContractName() string {
+	return spec.contract
+}
+
+// Offset returns the position of instance in the contract spec's instances list.
+func (spec *ContractInstanceSpec) Offset() int {
+	return spec.offset
+}
+
 func (spec *ContractInstanceSpec) TokenSymbol() string {
 	return spec.tokenSymbol
 }
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// GasReport is the gas used by WRITE steps of a target, along with the costs.
+// Saved reports are used as the baseline of later runs, to detect gas regressions.
+type GasReport struct {
+	Target string           `json:"target"`
+	Steps  []*GasReportStep `json:"steps"`
+	// TotalGasUsed and TotalFee are the sums over all steps, the fee is in ether.
+	TotalGasUsed uint64 `json:"totalGasUsed"`
+	TotalFee     string `json:"totalFee"`
+}
+
+type GasReportStep struct {
+	Command  string `json:"command"`
+	Wallet   string `json:"wallet,omitempty"`
+	TxHash   string `json:"tx"`
+	GasUsed  uint64 `json:"gasUsed"`
+	GasPrice string `json:"effectiveGasPrice"`
+	// Fee is in ether
+	Fee string `json:"fee"`
+	// Contract is the address of the deployed contract
+	Contract string `json:"contract,omitempty"`
+
+	feeWei *big.Int `json:"-"`
+}
+
+func NewGasReport(target string) *GasReport {
+	return &GasReport{
+		Target:   target,
+		Steps:    []*GasReportStep{},
+		TotalFee: "0",
+	}
+}
+
+func LoadGasReport(path string) (*GasReport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &GasReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (report *GasReport) Save(path string) error {
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Add appends the step with the fee in wei, the totals are updated.
+func (report *GasReport) Add(step *GasReportStep, fee *big.Int) {
+	step.feeWei = fee
+	step.Fee = weiToEther(fee)
+	report.Steps = append(report.Steps, step)
+	report.TotalGasUsed += step.GasUsed
+	total := new(big.Int)
+	for _, step := range report.Steps {
+		if step.feeWei != nil {
+			total.Add(total, step.feeWei)
+		}
+	}
+	report.TotalFee = weiToEther(total)
+}
+
+// GasUsedByCommand sums the gas used by the steps of each command.
+func (report *GasReport) GasUsedByCommand() map[string]uint64 {
+	gasUsed := make(map[string]uint64, len(report.Steps))
+	for _, step := range report.Steps {
+		gasUsed[step.Command] += step.GasUsed
+	}
+	return gasUsed
+}
+
+// GasRegression is a command that has used more gas than in the baseline.
+type GasRegression struct {
+	Command  string
+	Baseline uint64
+	GasUsed  uint64
+	// Growth is in percent of the baseline
+	Growth float64
+}
+
+// Regressions returns the commands which gas use has grown beyond the threshold in percent,
+// compared to the baseline. Commands missing in the baseline are not compared.
+func (report *GasReport) Regressions(baseline *GasReport, threshold float64) []*GasRegression {
+	baselineGas := baseline.GasUsedByCommand()
+	var regressions []*GasRegression
+	for cmd, gasUsed := range report.GasUsedByCommand() {
+		baseGas, ok := baselineGas[cmd]
+		if !ok || gasUsed <= baseGas {
+			continue
+		}
+		growth := 100.0
+		if baseGas > 0 {
+			growth = float64(gasUsed-baseGas) * 100 / float64(baseGas)
+		}
+		if growth > threshold {
+			regressions = append(regressions, &GasRegression{
+				Command:  cmd,
+				Baseline: baseGas,
+				GasUsed:  gasUsed,
+				Growth:   growth,
+			})
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].Command < regressions[j].Command
+	})
+	return regressions
+}
+
+var weiPerEther = big.NewInt(1e18)
+
+// weiToEther formats the amount of wei in ether, without trailing zeros.
+func weiToEther(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	ether := new(big.Rat).SetFrac(wei, weiPerEther).FloatString(18)
+	ether = strings.TrimRight(ether, "0")
+	return strings.TrimSuffix(ether, ".")
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasReport(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gas.json")
+
+	baseline := NewGasReport("deploy")
+	baseline.Add(&GasReportStep{Command: "deploy-token", GasUsed: 1000000}, big.NewInt(1e15))
+	baseline.Add(&GasReportStep{Command: "mint", GasUsed: 50000}, big.NewInt(5e13))
+	baseline.Add(&GasReportStep{Command: "mint", GasUsed: 30000}, big.NewInt(3e13))
+	assert.EqualValues(1080000, baseline.TotalGasUsed)
+	assert.Equal("0.00108", baseline.TotalFee)
+	assert.Equal("0.00005", baseline.Steps[1].Fee)
+	assert.NoError(baseline.Save(path))
+
+	loaded, err := LoadGasReport(path)
+	assert.NoError(err)
+	assert.Equal(map[string]uint64{"deploy-token": 1000000, "mint": 80000}, loaded.GasUsedByCommand())
+
+	report := NewGasReport("deploy")
+	report.Add(&GasReportStep{Command: "deploy-token", GasUsed: 1050000}, nil)
+	report.Add(&GasReportStep{Command: "mint", GasUsed: 100000}, nil)
+	report.Add(&GasReportStep{Command: "transfer", GasUsed: 40000}, nil)
+	assert.Equal("0", report.TotalFee)
+	regressions := report.Regressions(loaded, 10)
+	if assert.Len(regressions, 1) {
+		assert.Equal("mint", regressions[0].Command)
+		assert.EqualValues(80000, regressions[0].Baseline)
+		assert.EqualValues(100000, regressions[0].GasUsed)
+		assert.InDelta(25.0, regressions[0].Growth, 0.001)
+	}
+	assert.Len(report.Regressions(loaded, 1), 2)
+}
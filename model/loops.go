@@ -0,0 +1,265 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TargetLoopSpec lists the values of a loop step, the command is run once per value. Plain values are bound to $1,
+// or to the arg named by as, while mappings bind the values to several args by name (or position).
+// Alternatively, the loop iterates over the wallets matching the regexp, binding their addresses.
+type TargetLoopSpec struct {
+	Values  []interface{} `yaml:"values"`
+	Wallets string        `yaml:"wallets"`
+	As      string        `yaml:"as"`
+}
+
+func (spec *TargetLoopSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var values []interface{}
+	if err := unmarshal(&values); err == nil {
+		spec.Values = values
+		return nil
+	}
+	type plain TargetLoopSpec
+	return unmarshal((*plain)(spec))
+}
+
+// TargetIteration is an iteration of a loop step, with the values of args bound by their positions.
+type TargetIteration struct {
+	Label string
+	Args  map[int]string
+}
+
+// Context returns the context of the iteration, where the bound values replace the command args.
+func (iter *TargetIteration) Context(ctx AppContext) AppContext {
+	args := append([]string{}, ctx.AppCommandArgs()...)
+	for pos, value := range iter.Args {
+		for len(args) <= pos {
+			args = append(args, "")
+		}
+		args[pos] = value
+	}
+	return ctx.WithArgs(args)
+}
+
+// IsLoop reports whether the step runs its command for each value of for_each, or each combination of matrix.
+func (spec TargetCommandSpec) IsLoop() bool {
+	return spec.ForEach != nil || len(spec.Matrix) > 0
+}
+
+// Iterations returns the iterations of a loop step in order. Iterations of a matrix are all combinations
+// of the values of its variables, ordered by the variable names.
+func (spec TargetCommandSpec) Iterations(root *Spec) ([]*TargetIteration, error) {
+	switch {
+	case spec.ForEach != nil && len(spec.Matrix) > 0:
+		return nil, errors.New("step cannot have both for_each and matrix")
+	case spec.ForEach != nil:
+		return spec.forEachIterations(root)
+	case len(spec.Matrix) > 0:
+		return spec.matrixIterations(root)
+	}
+	return nil, nil
+}
+
+func (spec TargetCommandSpec) forEachIterations(root *Spec) ([]*TargetIteration, error) {
+	loop := spec.ForEach
+	as := loop.As
+	if len(as) == 0 {
+		as = "1"
+	}
+	if len(loop.Wallets) > 0 {
+		if len(loop.Values) > 0 {
+			return nil, errors.New("loop over wallets cannot have values")
+		}
+		rx, err := regexp.Compile(loop.Wallets)
+		if err != nil {
+			err = fmt.Errorf("failed to compile wallets regexp: %v", err)
+			return nil, err
+		}
+		pos, err := spec.loopArgPosition(root, as)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(root.Wallets))
+		for name := range root.Wallets {
+			if rx.MatchString(name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errors.New("no wallets are matching the loop regexp")
+		}
+		sort.Strings(names)
+		iterations := make([]*TargetIteration, 0, len(names))
+		for _, name := range names {
+			wallet := root.Wallets[name]
+			if len(wallet.Address) == 0 {
+				err := fmt.Errorf("wallet %s has no address", name)
+				return nil, err
+			}
+			iterations = append(iterations, &TargetIteration{
+				Label: name,
+				Args:  map[int]string{pos: wallet.Address},
+			})
+		}
+		return iterations, nil
+	}
+	if len(loop.Values) == 0 {
+		return nil, errors.New("loop has no values")
+	}
+	iterations := make([]*TargetIteration, 0, len(loop.Values))
+	for _, v := range loop.Values {
+		bindings, ok := loopBindings(v)
+		if !ok {
+			pos, err := spec.loopArgPosition(root, as)
+			if err != nil {
+				return nil, err
+			}
+			iterations = append(iterations, &TargetIteration{
+				Label: nillableStr(v),
+				Args:  map[int]string{pos: nillableStr(v)},
+			})
+			continue
+		}
+		names := make([]string, 0, len(bindings))
+		for name := range bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		iter := &TargetIteration{
+			Args: make(map[int]string, len(names)),
+		}
+		labels := make([]string, 0, len(names))
+		for _, name := range names {
+			pos, err := spec.loopArgPosition(root, name)
+			if err != nil {
+				return nil, err
+			} else if _, ok := iter.Args[pos]; ok {
+				err := fmt.Errorf("arg $%d is bound twice", pos)
+				return nil, err
+			}
+			iter.Args[pos] = nillableStr(bindings[name])
+			labels = append(labels, name+"="+iter.Args[pos])
+		}
+		iter.Label = strings.Join(labels, ",")
+		iterations = append(iterations, iter)
+	}
+	return iterations, nil
+}
+
+func (spec TargetCommandSpec) matrixIterations(root *Spec) ([]*TargetIteration, error) {
+	names := make([]string, 0, len(spec.Matrix))
+	for name := range spec.Matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	iterations := []*TargetIteration{{
+		Args: make(map[int]string),
+	}}
+	for _, name := range names {
+		values := spec.Matrix[name]
+		if len(values) == 0 {
+			err := fmt.Errorf("matrix variable %s has no values", name)
+			return nil, err
+		}
+		pos, err := spec.loopArgPosition(root, name)
+		if err != nil {
+			return nil, err
+		} else if _, ok := iterations[0].Args[pos]; ok {
+			err := fmt.Errorf("arg $%d is bound twice", pos)
+			return nil, err
+		}
+		combined := make([]*TargetIteration, 0, len(iterations)*len(values))
+		for _, iter := range iterations {
+			for _, v := range values {
+				args := make(map[int]string, len(iter.Args)+1)
+				for argPos, value := range iter.Args {
+					args[argPos] = value
+				}
+				args[pos] = nillableStr(v)
+				label := name + "=" + args[pos]
+				if len(iter.Label) > 0 {
+					label = iter.Label + "," + label
+				}
+				combined = append(combined, &TargetIteration{
+					Label: label,
+					Args:  args,
+				})
+			}
+		}
+		iterations = combined
+	}
+	return iterations, nil
+}
+
+// loopArgPosition returns the position of the arg bound by a loop, the arg is given by its position or name.
+func (spec TargetCommandSpec) loopArgPosition(root *Spec, name string) (int, error) {
+	name = strings.TrimPrefix(name, "$")
+	if pos, err := strconv.Atoi(name); err == nil {
+		if pos < 1 {
+			err := fmt.Errorf("loop cannot bind $%d, args start from $1", pos)
+			return 0, err
+		}
+		return pos, nil
+	}
+	if pos, ok := root.cmdArgSpecs(spec.Name()).Positions()[name]; ok {
+		return pos, nil
+	}
+	err := fmt.Errorf("arg %s is not declared by the command", name)
+	return 0, err
+}
+
+// loopBindings returns the values of a loop mapping by the arg names.
+func loopBindings(v interface{}) (map[string]interface{}, bool) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return vv, true
+	case map[interface{}]interface{}:
+		bindings := make(map[string]interface{}, len(vv))
+		for k, value := range vv {
+			bindings[nillableStr(k)] = value
+		}
+		return bindings, true
+	}
+	return nil, false
+}
+
+// validateLoop validates the command of a loop step with the args bound by each of the iterations.
+func (spec TargetCommandSpec) validateLoop(ctx AppContext, root *Spec, validateLog *log.Entry) bool {
+	loopLog := validateLog.WithField("command", spec.Name())
+	iterations, err := spec.Iterations(root)
+	if err != nil {
+		loopLog.WithError(err).Errorln("failed to expand the loop")
+		return false
+	}
+	for _, iter := range iterations {
+		if _, ok := root.BindCommand(iter.Context(ctx), spec.Name()); !ok {
+			loopLog.WithField("iteration", iter.Label).Errorln("command validation failed for the loop iteration")
+			return false
+		}
+	}
+	return true
+}
+
+// LoopArgs returns the positions of args bound by the loop steps of the target (and the targets it forwards
+// the args to), those are optional on the command line.
+func (spec TargetSpec) LoopArgs(root *Spec) map[int]struct{} {
+	set := make(map[int]struct{})
+	for _, target := range root.expandTarget(spec, true) {
+		for _, cmdSpec := range target {
+			iterations, _ := cmdSpec.Iterations(root)
+			for _, iter := range iterations {
+				for pos := range iter.Args {
+					set[pos] = struct{}{}
+				}
+			}
+		}
+	}
+	return set
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "github.com/xlab/yamlx"
+)
+
+func TestTargetLoops(t *testing.T) {
+	assert := assert.New(t)
+
+	root := &Spec{
+		Wallets: Wallets{
+			"alice": &WalletSpec{Address: "0xc6f6a3a2ba7a1e6ff4aa2e8a8c6c3b5b6b57e1a3"},
+			"bob":   &WalletSpec{Address: "0xa480763627636ff8b8ce97d0d6608e99fddb1062"},
+			"token": &WalletSpec{Address: "0x1bd2b9d7a2b2d5f6c5a3cf3eae54b7b8c8f3d1e2"},
+		},
+		WriteCmds: WriteCmds{
+			"send-wei": &WriteCmdSpec{
+				Args: ArgSpecs{{Name: "to", Type: ParamTypeAddress}, {Name: "amount"}},
+			},
+		},
+	}
+	var target TargetSpec
+	err := yaml.Unmarshal([]byte(`
+- run: send-wei
+  for_each: [100, 200]
+- run: send-wei
+  for_each:
+    wallets: "^(alice|bob)$"
+    as: to
+- run: send-wei
+  for_each:
+    - {to: "@alice", amount: 1}
+- run: send-wei
+  matrix:
+    to: ["@alice", "@bob"]
+    amount: [1, 2]
+- send-wei
+`), &target)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(target[0].IsLoop())
+	assert.False(target[4].IsLoop())
+
+	iterations, err := target[0].Iterations(root)
+	assert.NoError(err)
+	if assert.Len(iterations, 2) {
+		assert.Equal("100", iterations[0].Label)
+		assert.Equal(map[int]string{1: "100"}, iterations[0].Args)
+	}
+	iterations, err = target[1].Iterations(root)
+	assert.NoError(err)
+	if assert.Len(iterations, 2) {
+		assert.Equal("alice", iterations[0].Label)
+		assert.Equal(map[int]string{1: root.Wallets["alice"].Address}, iterations[0].Args)
+		assert.Equal("bob", iterations[1].Label)
+	}
+	iterations, err = target[2].Iterations(root)
+	assert.NoError(err)
+	if assert.Len(iterations, 1) {
+		assert.Equal("amount=1,to=@alice", iterations[0].Label)
+		assert.Equal(map[int]string{1: "@alice", 2: "1"}, iterations[0].Args)
+	}
+	iterations, err = target[3].Iterations(root)
+	assert.NoError(err)
+	if assert.Len(iterations, 4) {
+		assert.Equal("amount=1,to=@alice", iterations[0].Label)
+		assert.Equal("amount=1,to=@bob", iterations[1].Label)
+		assert.Equal("amount=2,to=@bob", iterations[3].Label)
+	}
+	assert.Equal(map[int]struct{}{1: {}, 2: {}}, target.LoopArgs(root))
+
+	ctx := NewAppContext(context.Background(), "airdrop", []string{"airdrop", "", "5"},
+		"genesis", "", "", nil, nil)
+	iterCtx := iterations[3].Context(ctx)
+	assert.Equal([]string{"airdrop", "@bob", "2"}, iterCtx.AppCommandArgs())
+	assert.Equal([]string{"airdrop", "", "5"}, ctx.AppCommandArgs())
+
+	_, err = TargetCommandSpec{Run: "send-wei", Matrix: map[string][]interface{}{"recipient": {1}}}.Iterations(root)
+	assert.Error(err)
+	_, err = TargetCommandSpec{Run: "send-wei", Matrix: map[string][]interface{}{"to": {1}, "1": {2}}}.Iterations(root)
+	assert.Error(err)
+	_, err = TargetCommandSpec{Run: "send-wei", ForEach: &TargetLoopSpec{Wallets: "^carol"}}.Iterations(root)
+	assert.Error(err)
+	_, err = TargetCommandSpec{Run: "send-wei", ForEach: &TargetLoopSpec{Values: []interface{}{1}, As: "0"}}.Iterations(root)
+	assert.Error(err)
+}
+
+func TestBindCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	root := &Spec{
+		CallCmds: CallCmds{
+			"balance": &CallCmdSpec{
+				Method: "eth_getBalance",
+				ParamSpec: ParamSpec{
+					Params: []interface{}{map[interface{}]interface{}{"type": "string", "reference": "$1"}},
+				},
+			},
+		},
+	}
+	ctx := NewAppContext(context.Background(), "airdrop", []string{"airdrop", "0x01"},
+		"genesis", "", "", nil, nil)
+	cmd := root.CallCmds["balance"]
+	if !assert.True(cmd.Validate(ctx, "balance", root)) {
+		return
+	}
+	bound, ok := root.BindCommand(ctx.WithArgs([]string{"airdrop", "0x02"}), "balance")
+	if assert.True(ok) {
+		assert.Equal([]interface{}{"0x02"}, bound.(*CallCmdSpec).ParamValues())
+	}
+	assert.Equal([]interface{}{"0x01"}, cmd.ParamValues())
+	_, ok = root.BindCommand(ctx, "unknown")
+	assert.False(ok)
+
+	instances := []*ContractInstanceSpec{
+		{Name: "Token"},
+		{Name: "Token", Address: "0xecc5c5b61f3833af29dcf5f1597f20ca0e6d4fa3", specAddress: "0xecc5c5b61f3833af29dcf5f1597f20ca0e6d4fa3"},
+	}
+	contract := &ContractSpec{Instances: instances}
+	// loaded from the deployment state
+	instances[0].Address = "0x3b47427740b5dedf1bfae36862a78d7134609607"
+	instance, ok := contract.FindInstance(instances[0])
+	assert.True(ok)
+	assert.True(instance == instances[0])
+	instance, ok = contract.FindInstance(&ContractInstanceSpec{Name: "Token"})
+	assert.True(ok)
+	assert.True(instance == instances[0])
+	instance, ok = contract.FindInstance(&ContractInstanceSpec{Address: "0xECC5C5B61F3833AF29DCF5F1597F20CA0E6D4FA3"})
+	assert.True(ok)
+	assert.True(instance == instances[1])
+	_, ok = contract.FindInstance(&ContractInstanceSpec{Address: "0x3b47427740b5dedf1bfae36862a78d7134609607"})
+	assert.False(ok)
+}
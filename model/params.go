@@ -16,10 +16,12 @@ type ParamSpec struct {
 	Params []interface{} `yaml:"params"`
 
 	paramValues []interface{} `yaml:"-"`
+	paramTypes  []ParamType   `yaml:"-"`
 }
 
 func (spec *ParamSpec) Validate(ctx AppContext, name string, root *Spec) bool {
 	spec.paramValues = make([]interface{}, len(spec.Params))
+	spec.paramTypes = make([]ParamType, len(spec.Params))
 	for paramID, param := range spec.Params {
 		if !spec.validateParam(ctx, name, root, NewEvaler(), paramID, param) {
 			return false
@@ -56,7 +58,43 @@ func (spec *ParamSpec) validateParam(ctx AppContext,
 			return false
 		}
 		paramType := ParamType(typ.(string))
+		spec.paramTypes[paramID] = paramType
 
+		if stepStr := valueStr + referenceStr; isStepRef(stepStr) {
+			ref, err := newStepReference(stepStr, paramType)
+			if err != nil {
+				validateLog.WithField("reference", stepStr).WithError(err).Errorln("failed to parse step reference")
+				return false
+			}
+			spec.paramValues[paramID] = ref // will be resolved later
+			return true
+		}
+
+		if paramType.IsComposite() {
+			value := p["value"]
+			if len(referenceStr) > 0 {
+				argsStr, err := substituteArgRefs(ctx, referenceStr)
+				if missing, ok := err.(*MissingArg); ok {
+					// the whole param unresolved
+					spec.paramValues[paramID] = missing
+					return true
+				} else if err != nil {
+					validateLog.WithField("reference", referenceStr).WithError(err).Errorln("failed to resolve reference")
+					return false
+				}
+				value = argsStr
+			}
+			composite, err := parseComposite(ctx, root, evaler, paramType, value)
+			if err != nil {
+				validateLog.WithFields(log.Fields{
+					"offset": paramID,
+					"type":   string(paramType),
+				}).WithError(err).Errorln("param parsing error, check type")
+				return false
+			}
+			spec.paramValues[paramID] = composite
+			return true
+		}
 		if len(referenceStr) > 0 {
 			refLog := validateLog.WithField("reference", referenceStr)
 			if isWalletRef(referenceStr) {
@@ -127,6 +165,17 @@ func (spec *ParamSpec) validateParam(ctx AppContext,
 			}
 		}
 	case string:
+		spec.paramTypes[paramID] = ParamTypeString
+		if isStepRef(p) {
+			// passed as-is to JSON-RPC calls
+			ref, err := newStepReference(p, "")
+			if err != nil {
+				validateLog.WithField("reference", p).WithError(err).Errorln("failed to parse step reference")
+				return false
+			}
+			spec.paramValues[paramID] = ref // will be resolved later
+			return true
+		}
 		spec.paramValues[paramID] = param
 	default:
 		validateLog.Errorln("unsupported param type: expected string or object {type, value}")
@@ -141,6 +190,9 @@ func (spec *ParamSpec) CountArgsUsing(set map[int]struct{}) {
 		if !ok {
 			continue
 		}
+		if typ, ok := p["type"].(string); ok && ParamType(typ).IsComposite() {
+			countCompositeArgsUsing(set, p["value"])
+		}
 		referenceStr := nillableStr(p["reference"])
 		if len(referenceStr) == 0 {
 			continue
@@ -354,8 +406,11 @@ func nillableStr(str interface{}) string {
 func newArgReference(ctx AppContext, value string) (*ArgReference, error) {
 	argID, err := strconv.Atoi(value[1:])
 	if err != nil {
-		err := errors.New("reference must be of the form $0, $1, etc")
-		return nil, err
+		var ok bool
+		if argID, ok = ctx.ArgNames()[value[1:]]; !ok {
+			err := errors.New("reference must be of the form $0, $1, etc or $name of a declared arg")
+			return nil, err
+		}
 	}
 	args := ctx.AppCommandArgs()
 	if argID > len(args)-1 {
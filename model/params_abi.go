@@ -0,0 +1,67 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	log "github.com/sirupsen/logrus"
+)
+
+// ValidateABI checks params against the inputs of a contract method (or constructor),
+// both the number of params and their types must match.
+func (spec *ParamSpec) ValidateABI(validateLog *log.Entry, inputs abi.Arguments) bool {
+	if len(spec.Params) != len(inputs) {
+		validateLog.WithFields(log.Fields{
+			"expected": len(inputs),
+			"actual":   len(spec.Params),
+		}).Errorln("param count mismatch with the contract ABI")
+		return false
+	}
+	for offset, input := range inputs {
+		paramType := spec.paramTypes[offset]
+		if !paramTypeMatches(paramType, input.Type) {
+			validateLog.WithFields(log.Fields{
+				"offset":   offset,
+				"param":    input.Name,
+				"type":     string(paramType),
+				"expected": input.Type.String(),
+			}).Errorln("param type mismatch with the contract ABI")
+			return false
+		}
+	}
+	return true
+}
+
+func paramTypeMatches(typ ParamType, abiType abi.Type) bool {
+	if elemType, size, ok := typ.ArrayElem(); ok {
+		switch abiType.T {
+		case abi.SliceTy:
+			return size < 0 && paramTypeMatches(elemType, *abiType.Elem)
+		case abi.ArrayTy:
+			return size == abiType.Size && paramTypeMatches(elemType, *abiType.Elem)
+		default:
+			return false
+		}
+	}
+	if types, _, ok := typ.TupleComponents(); ok {
+		if abiType.T != abi.TupleTy || len(types) != len(abiType.TupleElems) {
+			return false
+		}
+		for i, elemType := range types {
+			if !paramTypeMatches(elemType, *abiType.TupleElems[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	switch typ {
+	case ParamTypeInt:
+		// any size, range is checked upon packing
+		return abiType.T == abi.IntTy
+	case ParamTypeUInt:
+		// any size, range is checked upon packing
+		return abiType.T == abi.UintTy
+	case ParamTypeByte:
+		return abiType.T == abi.UintTy && abiType.Size == 8
+	default:
+		return string(typ) == abiType.String()
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamTypeMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	newType := func(typ string, components ...abi.ArgumentMarshaling) abi.Type {
+		abiType, err := abi.NewType(typ, components)
+		if err != nil {
+			panic(err)
+		}
+		return abiType
+	}
+	tuple := newType("tuple[]",
+		abi.ArgumentMarshaling{Name: "to", Type: "address"},
+		abi.ArgumentMarshaling{Name: "amount", Type: "uint256"},
+	)
+
+	assert.True(paramTypeMatches("uint256", newType("uint256")))
+	assert.True(paramTypeMatches("uint", newType("uint64")))
+	assert.True(paramTypeMatches("int", newType("int8")))
+	assert.False(paramTypeMatches("uint", newType("int256")))
+	assert.False(paramTypeMatches("uint128", newType("uint256")))
+	assert.True(paramTypeMatches("byte", newType("uint8")))
+	assert.True(paramTypeMatches("bytes32", newType("bytes32")))
+	assert.False(paramTypeMatches("string", newType("address")))
+	assert.True(paramTypeMatches("address[]", newType("address[]")))
+	assert.False(paramTypeMatches("address[2]", newType("address[]")))
+	assert.True(paramTypeMatches("uint[2]", newType("uint8[2]")))
+	assert.True(paramTypeMatches("tuple(address to,uint amount)[]", tuple))
+	assert.True(paramTypeMatches("(address,uint256)[]", tuple))
+	assert.False(paramTypeMatches("(address,bool)[]", tuple))
+}
@@ -0,0 +1,299 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompositeParam holds the elements of an array (T[], T[N]) or tuple param.
+// Elements may contain wallet references and placeholders, so the final value
+// is built for the ABI packer only after all references have been resolved.
+type CompositeParam struct {
+	Type  ParamType
+	Elems []interface{}
+}
+
+// MarshalJSON allows to pass composite params into JSON-RPC calls as arrays.
+func (p *CompositeParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Elems)
+}
+
+// IsComposite reports whether the type is an array, a fixed-size array or a tuple.
+func (typ ParamType) IsComposite() bool {
+	if _, _, ok := typ.ArrayElem(); ok {
+		return true
+	}
+	_, _, ok := typ.TupleComponents()
+	return ok
+}
+
+// ArrayElem returns the element type and size of array types,
+// the size is -1 for dynamic arrays (T[]).
+func (typ ParamType) ArrayElem() (ParamType, int, bool) {
+	str := strings.TrimSpace(string(typ))
+	if !strings.HasSuffix(str, "]") {
+		return "", 0, false
+	}
+	i := strings.LastIndex(str, "[")
+	if i <= 0 {
+		return "", 0, false
+	}
+	elemType := ParamType(str[:i])
+	sizeStr := str[i+1 : len(str)-1]
+	if len(sizeStr) == 0 {
+		return elemType, -1, true
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size <= 0 {
+		return "", 0, false
+	}
+	return elemType, size, true
+}
+
+// TupleComponents returns types and optional names of tuple components,
+// e.g. tuple(address to,uint256 amount) or just (address,uint256).
+func (typ ParamType) TupleComponents() ([]ParamType, []string, bool) {
+	str := strings.TrimSpace(string(typ))
+	str = strings.TrimPrefix(str, "tuple")
+	if !strings.HasPrefix(str, "(") || !strings.HasSuffix(str, ")") {
+		return nil, nil, false
+	}
+	parts := splitTopLevel(str[1:len(str)-1], ',')
+	if parts == nil {
+		return nil, nil, false
+	}
+	types := make([]ParamType, 0, len(parts))
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			return nil, nil, false
+		}
+		var name string
+		if i := strings.LastIndex(part, " "); i > 0 && !strings.ContainsAny(part[i:], "()[]") {
+			name = part[i+1:]
+			part = strings.TrimSpace(part[:i])
+		}
+		types = append(types, ParamType(part))
+		names = append(names, name)
+	}
+	return types, names, true
+}
+
+// splitTopLevel splits the string by separator, ignoring separators
+// nested in parentheses or brackets. Returns nil if the nesting is broken.
+func splitTopLevel(str string, sep rune) []string {
+	var parts []string
+	var depth, last int
+	for i, r := range str {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+			if depth < 0 {
+				return nil
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, str[last:i])
+				last = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil
+	}
+	return append(parts, str[last:])
+}
+
+// splitListStr splits a list provided as a string, e.g. from a CLI argument:
+// "0x1,0x2" or "[0x1, 0x2]".
+func splitListStr(str string) []interface{} {
+	str = strings.TrimSpace(str)
+	if strings.HasPrefix(str, "[") && strings.HasSuffix(str, "]") {
+		str = str[1 : len(str)-1]
+	} else if strings.HasPrefix(str, "(") && strings.HasSuffix(str, ")") {
+		str = str[1 : len(str)-1]
+	}
+	if len(strings.TrimSpace(str)) == 0 {
+		return []interface{}{}
+	}
+	parts := splitTopLevel(str, ',')
+	items := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		items = append(items, strings.TrimSpace(part))
+	}
+	return items
+}
+
+func parseComposite(ctx AppContext, root *Spec, evaler *Evaler,
+	typ ParamType, value interface{}) (*CompositeParam, error) {
+	if elemType, size, ok := typ.ArrayElem(); ok {
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case string:
+			items = splitListStr(v)
+		case nil:
+			items = []interface{}{}
+		default:
+			return nil, fmt.Errorf("expected a list of values for %s", typ)
+		}
+		if size >= 0 && len(items) != size {
+			return nil, fmt.Errorf("expected %d elements for %s, got %d", size, typ, len(items))
+		}
+		elems := make([]interface{}, len(items))
+		for i, item := range items {
+			elem, err := parseCompositeElem(ctx, root, evaler, elemType, item)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			elems[i] = elem
+		}
+		composite := &CompositeParam{
+			Type:  typ,
+			Elems: elems,
+		}
+		return composite, nil
+	}
+	types, names, ok := typ.TupleComponents()
+	if !ok {
+		return nil, fmt.Errorf("unsupported composite type: %s", typ)
+	}
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		items = splitListStr(v)
+	case map[interface{}]interface{}:
+		items = make([]interface{}, len(names))
+		for i, name := range names {
+			if len(name) == 0 {
+				return nil, fmt.Errorf("tuple components must be named to be set by name: %s", typ)
+			}
+			item, ok := v[name]
+			if !ok {
+				return nil, fmt.Errorf("tuple component is missing: %s", name)
+			}
+			items[i] = item
+		}
+		if len(v) != len(names) {
+			return nil, fmt.Errorf("unknown tuple components provided for %s", typ)
+		}
+	default:
+		return nil, fmt.Errorf("expected a list or a map of values for %s", typ)
+	}
+	if len(items) != len(types) {
+		return nil, fmt.Errorf("expected %d tuple components for %s, got %d", len(types), typ, len(items))
+	}
+	elems := make([]interface{}, len(items))
+	for i, item := range items {
+		elem, err := parseCompositeElem(ctx, root, evaler, types[i], item)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %v", i, err)
+		}
+		elems[i] = elem
+	}
+	composite := &CompositeParam{
+		Type:  typ,
+		Elems: elems,
+	}
+	return composite, nil
+}
+
+func parseCompositeElem(ctx AppContext, root *Spec, evaler *Evaler,
+	typ ParamType, item interface{}) (interface{}, error) {
+	if typ.IsComposite() {
+		return parseComposite(ctx, root, evaler, typ, item)
+	}
+	valueStr := nillableStr(item)
+	if isStepRef(valueStr) {
+		ref, err := newStepReference(valueStr, typ)
+		if err != nil {
+			return nil, err
+		}
+		return ref, nil // will be resolved later
+	}
+	if isWalletRef(valueStr) {
+		if valueStr[1:] == walletPrefix {
+			return PlaceholderAddr, nil // will be resolved later
+		}
+		ref, err := newWalletFieldReference(root, valueStr)
+		if err != nil {
+			return nil, err
+		}
+		return ref, nil // will be resolved later
+	}
+	valueStr, err := substituteArgRefs(ctx, valueStr)
+	if missing, ok := err.(*MissingArg); ok {
+		// unresolved until the arguments are provided, reported if used without them
+		return missing, nil
+	} else if err != nil {
+		return nil, err
+	}
+	v, ok := parseParam(evaler, typ, valueStr)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %q as %s", valueStr, typ)
+	}
+	return v, nil
+}
+
+// substituteArgRefs replaces $N and $name parts of the string with CLI arguments,
+// a *MissingArg error is returned if any of the arguments is not provided.
+func substituteArgRefs(ctx AppContext, str string) (string, error) {
+	parts := strings.Split(str, " ")
+	for i, part := range parts {
+		if !isArgRef(part) {
+			continue
+		}
+		ref, err := newArgReference(ctx, part)
+		if err != nil {
+			return "", err
+		} else if ref.ArgID < 0 {
+			return "", &MissingArg{Ref: part}
+		}
+		parts[i] = ctx.AppCommandArgs()[ref.ArgID]
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// MissingArg is the error of an argument that is not provided, referenced as $N or $name. Params keep it
+// in place of their values, unresolved until the arguments are provided, so it's reported once the param is used.
+type MissingArg struct {
+	Ref string
+}
+
+func (m *MissingArg) Error() string {
+	return fmt.Sprintf("argument %s is not provided", m.Ref)
+}
+
+// MarshalJSON fails, so the missing argument is not passed into JSON-RPC calls.
+func (m *MissingArg) MarshalJSON() ([]byte, error) {
+	return nil, m
+}
+
+func countCompositeArgsUsing(set map[int]struct{}, value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			countCompositeArgsUsing(set, item)
+		}
+	case map[interface{}]interface{}:
+		for _, item := range v {
+			countCompositeArgsUsing(set, item)
+		}
+	default:
+		for _, part := range strings.Split(nillableStr(v), " ") {
+			if isArgRef(part) {
+				if argID, err := argReferenceID(part); err == nil {
+					set[argID] = struct{}{}
+				}
+			}
+		}
+	}
+}
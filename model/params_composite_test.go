@@ -0,0 +1,115 @@
+package model
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamTypeComposite(t *testing.T) {
+	assert := assert.New(t)
+
+	elem, size, ok := ParamType("address[]").ArrayElem()
+	if assert.True(ok) {
+		assert.Equal(ParamTypeAddress, elem)
+		assert.Equal(-1, size)
+	}
+	elem, size, ok = ParamType("uint256[2][]").ArrayElem()
+	if assert.True(ok) {
+		assert.Equal(ParamType("uint256[2]"), elem)
+		assert.Equal(-1, size)
+	}
+	elem, size, ok = ParamType("tuple(address,uint256)[3]").ArrayElem()
+	if assert.True(ok) {
+		assert.Equal(ParamType("tuple(address,uint256)"), elem)
+		assert.Equal(3, size)
+	}
+	_, _, ok = ParamType("bytes32").ArrayElem()
+	assert.False(ok)
+	_, _, ok = ParamType("uint256[0]").ArrayElem()
+	assert.False(ok)
+
+	types, names, ok := ParamType("tuple(address to, uint256[] amounts, tuple(bool,string) meta)").TupleComponents()
+	if assert.True(ok) {
+		assert.Equal([]ParamType{"address", "uint256[]", "tuple(bool,string)"}, types)
+		assert.Equal([]string{"to", "amounts", "meta"}, names)
+	}
+	types, names, ok = ParamType("(bool,string)").TupleComponents()
+	if assert.True(ok) {
+		assert.Equal([]ParamType{"bool", "string"}, types)
+		assert.Equal([]string{"", ""}, names)
+	}
+	_, _, ok = ParamType("tuple(bool,string").TupleComponents()
+	assert.False(ok)
+
+	assert.True(ParamType("bool[]").IsComposite())
+	assert.True(ParamType("tuple(bool)").IsComposite())
+	assert.False(ParamType("uint256").IsComposite())
+}
+
+func TestParseComposite(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewAppContext(context.Background(), "batch-mint", []string{"batch-mint", "7"},
+		"genesis", "", "", nil, nil)
+	root := &Spec{
+		Wallets: Wallets{
+			"bob": &WalletSpec{
+				Address: "0xa480763627636ff8b8ce97d0d6608e99fddb1062",
+			},
+		},
+	}
+	addresses, err := parseComposite(ctx, root, NewEvaler(), "address[]", []interface{}{
+		"@bob", "@@", "0xddb987896df947ee5aeb2bbb5d387008ed9dceef",
+	})
+	if assert.NoError(err) {
+		assert.Equal([]interface{}{
+			&WalletFieldReference{WalletName: "bob", FieldName: WalletSpecAddressField},
+			PlaceholderAddr,
+			common.HexToAddress("0xddb987896df947ee5aeb2bbb5d387008ed9dceef"),
+		}, addresses.Elems)
+	}
+	amounts, err := parseComposite(ctx, root, NewEvaler(), "uint256[2]", []interface{}{
+		"100 * 1e18", "$1",
+	})
+	expected, ok := big.NewInt(0).SetString("100000000000000000000", 10)
+	if !ok {
+		t.Fatal("failed to parse the expected amount")
+	}
+	if assert.NoError(err) {
+		assert.Equal([]interface{}{expected, big.NewInt(7)}, amounts.Elems)
+	}
+	_, err = parseComposite(ctx, root, NewEvaler(), "uint256[2]", []interface{}{"1"})
+	assert.Error(err)
+	// missing args are reported once the param is used
+	namedCtx := ctx.WithArgNames(map[string]int{"amount": 3})
+	missing, err := parseComposite(namedCtx, root, NewEvaler(), "tuple(uint256 a,uint256[] b)", []interface{}{
+		"$2", []interface{}{"$amount"},
+	})
+	if assert.NoError(err) && assert.Len(missing.Elems, 2) {
+		assert.Equal(&MissingArg{Ref: "$2"}, missing.Elems[0])
+		assert.Equal([]interface{}{&MissingArg{Ref: "$amount"}}, missing.Elems[1].(*CompositeParam).Elems)
+		assert.EqualError(missing.Elems[0].(error), "argument $2 is not provided")
+	}
+
+	tuple, err := parseComposite(ctx, root, NewEvaler(), "tuple(address to,uint8[] flags)",
+		map[interface{}]interface{}{
+			"to":    "@bob",
+			"flags": "1,2",
+		})
+	if assert.NoError(err) {
+		assert.Len(tuple.Elems, 2)
+		assert.Equal(&CompositeParam{
+			Type:  "uint8[]",
+			Elems: []interface{}{uint8(1), uint8(2)},
+		}, tuple.Elems[1])
+	}
+	_, err = parseComposite(ctx, root, NewEvaler(), "tuple(address to,uint8[] flags)",
+		map[interface{}]interface{}{
+			"to": "@bob",
+		})
+	assert.Error(err)
+}
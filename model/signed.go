@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SignedTxs is a file of transactions signed offline, in sign-only mode,
+// which are submitted later by the broadcast command.
+type SignedTxs struct {
+	Transactions []*SignedTx `json:"transactions"`
+
+	path string      `json:"-"`
+	mux  *sync.Mutex `json:"-"`
+}
+
+type SignedTx struct {
+	Command string `json:"command"`
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"`
+	Nonce   uint64 `json:"nonce"`
+	Gas     uint64 `json:"gas"`
+	ChainID string `json:"chainID"`
+	Hash    string `json:"hash"`
+	Raw     string `json:"raw"`
+
+	// set for contract deployments
+	Contract   string `json:"contract,omitempty"`
+	Instance   int    `json:"instance,omitempty"`
+	InstanceID string `json:"instanceID,omitempty"`
+	Address    string `json:"address,omitempty"`
+}
+
+// NewSignedTxs returns an empty file of signed transactions, the file at path gets overwritten.
+func NewSignedTxs(path string) *SignedTxs {
+	return &SignedTxs{
+		Transactions: []*SignedTx{},
+
+		path: path,
+		mux:  new(sync.Mutex),
+	}
+}
+
+func LoadSignedTxs(path string) (*SignedTxs, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	txs := NewSignedTxs(path)
+	if err := json.Unmarshal(data, txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (txs *SignedTxs) Path() string {
+	return txs.path
+}
+
+// Add appends the signed transaction and saves the file.
+func (txs *SignedTxs) Add(tx *SignedTx) error {
+	txs.mux.Lock()
+	defer txs.mux.Unlock()
+	txs.Transactions = append(txs.Transactions, tx)
+	data, err := json.MarshalIndent(txs, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(txs.path, data, 0644)
+}
+
+// ParseNonces parses the starting nonces of wallets in sign-only mode, e.g. "alice=5,bob=0".
+func ParseNonces(s string) (map[string]uint64, error) {
+	nonces := make(map[string]uint64)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			err := fmt.Errorf("nonce must be specified as wallet=N: %s", pair)
+			return nil, err
+		}
+		name := strings.TrimSpace(parts[0])
+		nonce, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			err = fmt.Errorf("failed to parse nonce of wallet %s: %v", name, err)
+			return nil, err
+		}
+		nonces[name] = nonce
+	}
+	return nonces, nil
+}
+
+// validateSignOnly ensures that the command or target can be run without a node,
+// i.e. consists of WRITE commands, and the nonces are given for known wallets.
+// Conditions of steps and values denominated in tokens need the node, so they're not allowed.
+func (spec *Spec) validateSignOnly(ctx AppContext, validateLog *log.Entry) bool {
+	for name := range ctx.SignNonces() {
+		if _, ok := spec.Wallets.WalletSpec(name); !ok {
+			validateLog.WithField("wallet", name).Errorln("nonce is given for unknown wallet")
+			return false
+		}
+	}
+	cmdNames := []string{ctx.AppCommand()}
+	if target, ok := spec.Targets[ctx.AppCommand()]; ok {
+		cmdNames = spec.TargetCmdNames(ctx.AppCommand())
+		for _, target := range spec.expandTarget(target, false) {
+			for _, cmdSpec := range target {
+				if len(cmdSpec.When) > 0 {
+					validateLog.WithFields(log.Fields{
+						"command": cmdSpec.Name(),
+						"when":    string(cmdSpec.When),
+					}).Errorln("conditions of steps are evaluated on the node, they can't be used offline")
+					return false
+				}
+			}
+		}
+	}
+	for _, name := range cmdNames {
+		cmdSpec, ok := spec.WriteCmds[name]
+		if !ok {
+			validateLog.WithField("command", name).Errorln("only WRITE commands can be signed offline")
+			return false
+		}
+		if symbol, ok := cmdSpec.Value.TokenDenominator(); ok {
+			validateLog.WithFields(log.Fields{
+				"command": name,
+				"symbol":  symbol,
+			}).Errorln("token symbols are fetched from the node, values in tokens can't be signed offline")
+			return false
+		}
+	}
+	return true
+}
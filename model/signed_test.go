@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNonces(t *testing.T) {
+	assert := assert.New(t)
+
+	nonces, err := ParseNonces("alice=5, bob=0")
+	assert.NoError(err)
+	assert.Equal(map[string]uint64{"alice": 5, "bob": 0}, nonces)
+
+	nonces, err = ParseNonces("")
+	assert.NoError(err)
+	assert.Empty(nonces)
+
+	_, err = ParseNonces("alice")
+	assert.Error(err)
+	_, err = ParseNonces("alice=-1")
+	assert.Error(err)
+}
+
+func TestSignedTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signed.json")
+
+	txs := NewSignedTxs(path)
+	assert.NoError(txs.Add(&SignedTx{
+		Command: "deploy-token",
+		From:    "0xddb987896df947ee5aeb2bbb5d387008ed9dceef",
+		Nonce:   5,
+		Gas:     10000000,
+		ChainID: "1",
+		Hash:    "0x5d44f37d75017c7bec7f93958b91dd4960a6621677ca6625a0545328731799da",
+		Raw:     "0xf8d4",
+
+		Contract: "token",
+		Address:  "0xa0981261264853c096e4f292f441816dd14d2cd2",
+	}))
+	assert.NoError(txs.Add(&SignedTx{
+		Command: "send-1-eth",
+		From:    "0xddb987896df947ee5aeb2bbb5d387008ed9dceef",
+		To:      "0x5e2b23eeab4d0a6e79578d3479a6a37466a34a4c",
+		Nonce:   6,
+		Gas:     21000,
+		ChainID: "1",
+		Hash:    "0x986b6d17f8552c9fd79ea680176f1c1951fce426bd6ceb92d37901a7163e6b34",
+		Raw:     "0xf86c",
+	}))
+
+	loaded, err := LoadSignedTxs(path)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(path, loaded.Path())
+	assert.Equal(txs.Transactions, loaded.Transactions)
+
+	_, err = LoadSignedTxs(filepath.Join(dir, "missing.json"))
+	assert.Error(err)
+}
+
+func TestValidateSignOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	validateLog := log.WithField("test", "TestValidateSignOnly")
+	root := &Spec{
+		Wallets: Wallets{
+			"alice": &WalletSpec{Address: "0xc6f6a3a2ba7a1e6ff4aa2e8a8c6c3b5b6b57e1a3"},
+		},
+		WriteCmds: WriteCmds{
+			"send-wei":    &WriteCmdSpec{Value: "100 * 2 gwei"},
+			"send-tokens": &WriteCmdSpec{Value: "100 TKN"},
+		},
+		Targets: Targets{
+			"send":      TargetSpec{{Run: "send-wei"}},
+			"send-if":   TargetSpec{{Run: "send-wei", When: "@alice.balance > 0"}},
+			"nested-if": TargetSpec{{Run: "send"}, {Run: "send-if"}},
+			"airdrop":   TargetSpec{{Run: "send"}, {Run: "send-tokens"}},
+		},
+	}
+	signOnly := func(name string) AppContext {
+		ctx := NewAppContext(context.Background(), name, []string{name}, "genesis", "", "", nil, nil)
+		return ctx.WithSignOnly("signed.json", map[string]uint64{"alice": 1})
+	}
+	assert.True(root.validateSignOnly(signOnly("send"), validateLog))
+	assert.True(root.validateSignOnly(signOnly("send-wei"), validateLog))
+	// conditions are evaluated on the node
+	assert.False(root.validateSignOnly(signOnly("send-if"), validateLog))
+	assert.False(root.validateSignOnly(signOnly("nested-if"), validateLog))
+	// token symbols are fetched from the node
+	assert.False(root.validateSignOnly(signOnly("send-tokens"), validateLog))
+	assert.False(root.validateSignOnly(signOnly("airdrop"), validateLog))
+
+	symbol, ok := Valuer("100 TKN").TokenDenominator()
+	assert.True(ok)
+	assert.Equal("tkn", symbol)
+	_, ok = Valuer("1 ether").TokenDenominator()
+	assert.False(ok)
+	_, ok = Valuer("$amount").TokenDenominator()
+	assert.False(ok)
+	_, ok = Valuer("@alice.balance / 2").TokenDenominator()
+	assert.False(ok)
+}
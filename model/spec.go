@@ -12,8 +12,10 @@ type Spec struct {
 	ViewCmds  ViewCmds  `yaml:"VIEW"`
 	WriteCmds WriteCmds `yaml:"WRITE"`
 	CallCmds  CallCmds  `yaml:"CALL"`
+	EventCmds EventCmds `yaml:"EVENTS"`
 
 	uniqueNames map[string]struct{} `yaml:"-"`
+	state       *DeploymentState    `yaml:"-"`
 }
 
 func (spec *Spec) Validate(ctx AppContext) bool {
@@ -27,17 +29,8 @@ func (spec *Spec) Validate(ctx AppContext) bool {
 		validateLog.Errorln("config spec validation failed")
 		return false
 	}
-	if len(ctx.AppCommand()) > 0 {
-		if spec.Inventory == nil {
-			validateLog.Errorln("spec must contain INVENTORY section")
-			return false
-		} else if !spec.Inventory.Validate(ctx, spec) {
-			validateLog.Errorln("inventory spec validation failed")
-			return false
-		}
-	}
-	if spec.ViewCmds == nil && spec.WriteCmds == nil && spec.CallCmds == nil {
-		validateLog.Errorln("spec must contain at least one of VIEW, WRITE or CALL sections")
+	if spec.ViewCmds == nil && spec.WriteCmds == nil && spec.CallCmds == nil && spec.EventCmds == nil {
+		validateLog.Errorln("spec must contain at least one of VIEW, WRITE, CALL or EVENTS sections")
 		return false
 	}
 	if spec.Wallets != nil {
@@ -55,6 +48,11 @@ func (spec *Spec) Validate(ctx AppContext) bool {
 			return false
 		}
 	}
+	// arg values are checked before they are used in params
+	if !spec.validateArgs(ctx) {
+		validateLog.Errorln("args validation failed")
+		return false
+	}
 	spec.uniqueNames = make(map[string]struct{})
 	if spec.CallCmds != nil {
 		if !spec.CallCmds.Validate(ctx, spec) {
@@ -74,15 +72,66 @@ func (spec *Spec) Validate(ctx AppContext) bool {
 			return false
 		}
 	}
+	if spec.EventCmds != nil {
+		if !spec.EventCmds.Validate(ctx, spec) {
+			validateLog.Errorln("event cmds spec validation failed")
+			return false
+		}
+	}
 	if spec.Targets != nil {
 		if !spec.Targets.Validate(ctx, spec) {
 			validateLog.Errorln("targets spec validation failed")
 			return false
 		}
 	}
+	if refs := spec.StepReferences(ctx.AppCommand()); len(refs) > 0 {
+		validateLog.WithFields(log.Fields{
+			"command":   ctx.AppCommand(),
+			"reference": refs[0].String(),
+		}).Errorln("step references can only be used by commands run within targets")
+		return false
+	}
+	if ctx.IsSignOnly() && len(ctx.AppCommand()) > 0 {
+		if !spec.validateSignOnly(ctx, validateLog) {
+			validateLog.Errorln("sign-only mode validation failed")
+			return false
+		}
+		// transactions are signed offline
+		return true
+	}
+	// nodes are checked last, so the spec errors are reported without going online
+	if len(ctx.AppCommand()) > 0 {
+		if spec.Inventory == nil {
+			validateLog.Errorln("spec must contain INVENTORY section")
+			return false
+		} else if !spec.Inventory.Validate(ctx, spec) {
+			validateLog.Errorln("inventory spec validation failed")
+			return false
+		}
+	}
 	return true
 }
 
+// HasCommand reports whether there is a target or a command with the name.
+func (spec *Spec) HasCommand(name string) bool {
+	if _, ok := spec.Targets[name]; ok {
+		return true
+	} else if _, ok := spec.CallCmds[name]; ok {
+		return true
+	} else if _, ok := spec.ViewCmds[name]; ok {
+		return true
+	} else if _, ok := spec.WriteCmds[name]; ok {
+		return true
+	} else if _, ok := spec.EventCmds[name]; ok {
+		return true
+	}
+	return false
+}
+
+func (spec *Spec) DeploymentState() *DeploymentState {
+	return spec.state
+}
+
 func (spec *Spec) CountArgsUsing(set map[int]struct{}, name string) {
 	if cmd, ok := spec.CallCmds[name]; ok {
 		cmd.CountArgsUsing(set)
@@ -90,6 +139,8 @@ func (spec *Spec) CountArgsUsing(set map[int]struct{}, name string) {
 		cmd.CountArgsUsing(set)
 	} else if cmd, ok := spec.WriteCmds[name]; ok {
 		cmd.CountArgsUsing(set)
+	} else if cmd, ok := spec.EventCmds[name]; ok {
+		cmd.CountArgsUsing(set)
 	}
 }
 
@@ -100,8 +151,29 @@ func (spec *Spec) ArgCount(name string) int {
 		return cmd.ArgCount()
 	} else if cmd, ok := spec.WriteCmds[name]; ok {
 		return cmd.ArgCount()
+	} else if cmd, ok := spec.EventCmds[name]; ok {
+		return cmd.ArgCount()
 	}
 	return 0
 }
 
+// BindCommand returns a copy of the command validated with the context, so its params get the values
+// of the context args, e.g. bound by a loop step. The command itself is left intact, as steps may run concurrently.
+func (spec *Spec) BindCommand(ctx AppContext, name string) (interface{}, bool) {
+	if cmd, ok := spec.CallCmds[name]; ok {
+		bound := *cmd
+		return &bound, bound.Validate(ctx, name, spec)
+	} else if cmd, ok := spec.ViewCmds[name]; ok {
+		bound := *cmd
+		return &bound, bound.Validate(ctx, name, spec)
+	} else if cmd, ok := spec.WriteCmds[name]; ok {
+		bound := *cmd
+		return &bound, bound.Validate(ctx, name, spec)
+	} else if cmd, ok := spec.EventCmds[name]; ok {
+		bound := *cmd
+		return &bound, bound.Validate(ctx, name, spec)
+	}
+	return nil, false
+}
+
 type FieldName string
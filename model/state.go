@@ -0,0 +1,199 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DeploymentState keeps track of contract instances deployed by the playbook,
+// so the addresses survive between runs. There is one state file per inventory group.
+type DeploymentState struct {
+	Contracts map[string][]*DeployedInstance `json:"contracts"`
+
+	path string
+	mux  *sync.Mutex
+}
+
+// DeployedInstance is the record of a deployment, keyed by the id of the instance if it has one,
+// or by its offset in the instances list otherwise. Block is set once the deployment is mined.
+type DeployedInstance struct {
+	ID       string `json:"id,omitempty"`
+	Instance int    `json:"instance"`
+	Address  string `json:"address"`
+	TxHash   string `json:"tx"`
+	// Replaced are the hashes of the transactions replaced with TxHash, e.g. with bumped fees,
+	// any of them may get mined
+	Replaced []string `json:"replaced,omitempty"`
+	Block    uint64   `json:"block"`
+	Deployer string   `json:"deployer"`
+}
+
+// DeploymentStatePath returns the path of state file that is located next to the spec,
+// e.g. playbook.genesis.state.json for playbook.yml and genesis inventory group.
+func DeploymentStatePath(specPath, nodeGroup string) string {
+	dir := filepath.Dir(specPath)
+	name := strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))
+	return filepath.Join(dir, fmt.Sprintf("%s.%s.state.json", name, nodeGroup))
+}
+
+func LoadDeploymentState(path string) (*DeploymentState, error) {
+	state := &DeploymentState{
+		Contracts: make(map[string][]*DeployedInstance),
+
+		path: path,
+		mux:  new(sync.Mutex),
+	}
+	if len(path) == 0 {
+		return state, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Contracts == nil {
+		state.Contracts = make(map[string][]*DeployedInstance)
+	}
+	return state, nil
+}
+
+func (state *DeploymentState) Path() string {
+	return state.path
+}
+
+// Instance returns the record of the contract instance, by its id if set, or by its offset.
+func (state *DeploymentState) Instance(contract, id string, offset int) (*DeployedInstance, bool) {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	for _, deployed := range state.Contracts[contract] {
+		if deployed.hasKey(id, offset) {
+			return deployed, true
+		}
+	}
+	return nil, false
+}
+
+// Record saves a freshly deployed instance, replacing any previous record for it.
+func (state *DeploymentState) Record(contract string, deployed *DeployedInstance) error {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	instances := state.Contracts[contract]
+	for i, prev := range instances {
+		if prev.hasKey(deployed.ID, deployed.Instance) {
+			instances[i] = deployed
+			return state.save()
+		}
+	}
+	state.Contracts[contract] = append(instances, deployed)
+	return state.save()
+}
+
+func (deployed *DeployedInstance) hasKey(id string, offset int) bool {
+	if len(id) > 0 || len(deployed.ID) > 0 {
+		return deployed.ID == id
+	}
+	return deployed.Instance == offset
+}
+
+// Confirm sets the block number of a deployment once its transaction is mined,
+// the transaction may be either the last one sent for the deployment or any of those it has replaced.
+func (state *DeploymentState) Confirm(txHash string, block uint64) error {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	txHash = strings.ToLower(txHash)
+	for _, instances := range state.Contracts {
+		for _, deployed := range instances {
+			if deployed.sentWith(txHash) {
+				deployed.TxHash = txHash
+				deployed.Replaced = nil
+				deployed.Block = block
+				return state.save()
+			}
+		}
+	}
+	return nil
+}
+
+// Replace updates the deployment made by a transaction that has been replaced, e.g. with bumped fees.
+// The replaced transaction is kept, since it may still get mined instead.
+func (state *DeploymentState) Replace(txHash, newTxHash string) error {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	txHash = strings.ToLower(txHash)
+	for _, instances := range state.Contracts {
+		for _, deployed := range instances {
+			if deployed.sentWith(txHash) {
+				deployed.Replaced = append(deployed.Replaced, deployed.TxHash)
+				deployed.TxHash = strings.ToLower(newTxHash)
+				return state.save()
+			}
+		}
+	}
+	return nil
+}
+
+// Forget removes the deployment made by a transaction that has failed.
+func (state *DeploymentState) Forget(txHash string) error {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	txHash = strings.ToLower(txHash)
+	for contract, instances := range state.Contracts {
+		for i, deployed := range instances {
+			if deployed.sentWith(txHash) {
+				state.Contracts[contract] = append(instances[:i], instances[i+1:]...)
+				return state.save()
+			}
+		}
+	}
+	return nil
+}
+
+// sentWith reports whether the transaction has been sent for the deployment.
+func (deployed *DeployedInstance) sentWith(txHash string) bool {
+	if deployed.TxHash == txHash {
+		return true
+	}
+	for _, hash := range deployed.Replaced {
+		if hash == txHash {
+			return true
+		}
+	}
+	return false
+}
+
+func (state *DeploymentState) save() error {
+	if len(state.path) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+	// written into a temp file first, so an interrupted write doesn't truncate the records of live deployments
+	f, err := ioutil.TempFile(filepath.Dir(state.path), filepath.Base(state.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	} else if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), state.path)
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentStatePath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(filepath.Join("examples", "tokens.genesis.state.json"),
+		DeploymentStatePath(filepath.Join("examples", "tokens.yml"), "genesis"))
+	assert.Equal("playbook.testnet.state.json",
+		DeploymentStatePath("playbook.yml", "testnet"))
+}
+
+func TestDeploymentState(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "playbook")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "playbook.genesis.state.json")
+
+	state, err := LoadDeploymentState(path)
+	if !assert.NoError(err) {
+		return
+	}
+	_, ok := state.Instance("token", "", 0)
+	assert.False(ok)
+
+	assert.NoError(state.Record("token", &DeployedInstance{
+		Instance: 1,
+		Address:  "0xecc5c5b61f3833af29dcf5f1597f20ca0e6d4fa3",
+		TxHash:   "0x80c8b1eca7fce7f227782853a0ed8f8acc979de1d371aa6bbf0e7269b7dc7081",
+		Deployer: "0xa480763627636ff8b8ce97d0d6608e99fddb1062",
+	}))
+	assert.NoError(state.Record("token", &DeployedInstance{
+		Instance: 0,
+		Address:  "0x3b47427740b5dedf1bfae36862a78d7134609607",
+		TxHash:   "0x768baa938f383c8943f84d4385a6439c3a3e3b262b3f99568ed44d654fd711f2",
+	}))
+	assert.NoError(state.Confirm("0x80C8B1ECA7FCE7F227782853A0ED8F8ACC979DE1D371AA6BBF0E7269B7DC7081", 42))
+	assert.NoError(state.Forget("0x768baa938f383c8943f84d4385a6439c3a3e3b262b3f99568ed44d654fd711f2"))
+	// saved via temp files, which are not left behind
+	files, err := ioutil.ReadDir(dir)
+	if assert.NoError(err) && assert.Len(files, 1) {
+		assert.Equal(filepath.Base(path), files[0].Name())
+		assert.Equal(os.FileMode(0644), files[0].Mode().Perm())
+	}
+
+	loaded, err := LoadDeploymentState(path)
+	if !assert.NoError(err) {
+		return
+	}
+	_, ok = loaded.Instance("token", "", 0)
+	assert.False(ok)
+	deployed, ok := loaded.Instance("token", "", 1)
+	if assert.True(ok) {
+		assert.Equal("0xecc5c5b61f3833af29dcf5f1597f20ca0e6d4fa3", deployed.Address)
+		assert.EqualValues(42, deployed.Block)
+	}
+}
+
+func TestLoadDeployments(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := LoadDeploymentState("")
+	if !assert.NoError(err) {
+		return
+	}
+	records := []*DeployedInstance{
+		{Instance: 0, Address: "0x3b47427740b5dedf1bfae36862a78d7134609607", Block: 7},
+		{ID: "bonus", Instance: 2, Address: "0xecc5c5b61f3833af29dcf5f1597f20ca0e6d4fa3", Block: 8},
+		{ID: "pending", Instance: 1, Address: "0xa480763627636ff8b8ce97d0d6608e99fddb1062"},
+	}
+	for _, deployed := range records {
+		assert.NoError(state.Record("token", deployed))
+	}
+	deployed, ok := state.Instance("token", "bonus", 0)
+	assert.True(ok)
+	assert.Equal(records[1], deployed)
+	_, ok = state.Instance("token", "", 2)
+	assert.False(ok)
+	// replaces the record with the same id
+	assert.NoError(state.Record("token", &DeployedInstance{ID: "bonus", Instance: 5, Block: 9}))
+	deployed, _ = state.Instance("token", "bonus", 0)
+	assert.EqualValues(9, deployed.Block)
+	assert.NoError(state.Record("token", records[1]))
+
+	contract := &ContractSpec{
+		Instances: []*ContractInstanceSpec{
+			{Name: "token"},
+			{Name: "token", ID: "pending"},
+			{Name: "token", ID: "bonus"},
+		},
+	}
+	contract.loadDeployments("token", state)
+	assert.Equal(records[0].Address, contract.Instances[0].Address)
+	// not mined
+	assert.Empty(contract.Instances[1].Address)
+	assert.Equal(records[1].Address, contract.Instances[2].Address)
+
+	// instances without ids are ambiguous once there are several to deploy
+	contract = &ContractSpec{
+		Instances: []*ContractInstanceSpec{{Name: "token"}, {Name: "token"}},
+	}
+	contract.loadDeployments("token", state)
+	assert.Empty(contract.Instances[0].Address)
+}
+
+func TestDeploymentStateReplace(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := LoadDeploymentState("")
+	if !assert.NoError(err) {
+		return
+	}
+	const (
+		original = "0x768baa938f383c8943f84d4385a6439c3a3e3b262b3f99568ed44d654fd711f2"
+		bumped   = "0x80c8b1eca7fce7f227782853a0ed8f8acc979de1d371aa6bbf0e7269b7dc7081"
+		again    = "0x0b1fa8f0b6e9b5c4b6d1f2a1f5b9a8d0e7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2"
+	)
+	assert.NoError(state.Record("token", &DeployedInstance{
+		Address: "0x3b47427740b5dedf1bfae36862a78d7134609607",
+		TxHash:  original,
+	}))
+	assert.NoError(state.Replace(original, bumped))
+	assert.NoError(state.Replace(bumped, again))
+	deployed, _ := state.Instance("token", "", 0)
+	assert.Equal(again, deployed.TxHash)
+	assert.Equal([]string{original, bumped}, deployed.Replaced)
+
+	// the original transaction is mined instead of its replacements
+	assert.NoError(state.Confirm(original, 42))
+	deployed, _ = state.Instance("token", "", 0)
+	assert.Equal(original, deployed.TxHash)
+	assert.Empty(deployed.Replaced)
+	assert.EqualValues(42, deployed.Block)
+
+	assert.NoError(state.Replace(original, bumped))
+	assert.NoError(state.Forget(original))
+	_, ok := state.Instance("token", "", 0)
+	assert.False(ok)
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const stepPrefix string = "#"
+
+func isStepRef(str string) bool {
+	return strings.HasPrefix(str, stepPrefix)
+}
+
+type StepField string
+
+const (
+	// StepAddressField is the address of contract instance (or recipient) used by the step.
+	StepAddressField StepField = "address"
+	// StepTxField is the hash of transaction sent by the step.
+	StepTxField StepField = "tx"
+	// StepResultField is the result of the step, may be followed by [N] indices or .key fields.
+	StepResultField StepField = "result"
+)
+
+// StepReference references a result of an earlier step of the target,
+// e.g. #deploy-token.address, #get-owner.result[0] or #send.tx.
+type StepReference struct {
+	Step  string
+	Field StepField
+	// Path contains int indices and string keys to select from the result.
+	Path []interface{}
+	// Type is the param type the value is converted to, empty to use the value as-is.
+	Type ParamType
+}
+
+func newStepReference(value string, typ ParamType) (*StepReference, error) {
+	str := strings.TrimPrefix(strings.TrimSpace(value), stepPrefix)
+	i := strings.Index(str, refDelim)
+	if i <= 0 {
+		err := errors.New("step reference must be of the form #step.field")
+		return nil, err
+	}
+	ref := &StepReference{
+		Step: str[:i],
+		Type: typ,
+	}
+	str = str[i+1:]
+	fieldEnd := strings.IndexAny(str, ".[")
+	if fieldEnd < 0 {
+		fieldEnd = len(str)
+	}
+	ref.Field = StepField(str[:fieldEnd])
+	switch ref.Field {
+	case StepAddressField, StepTxField, StepResultField:
+	default:
+		err := fmt.Errorf("unknown step field: %s (expected address, tx or result)", ref.Field)
+		return nil, err
+	}
+	path, err := parseStepPath(str[fieldEnd:])
+	if err != nil {
+		return nil, err
+	} else if len(path) > 0 && ref.Field != StepResultField {
+		err := fmt.Errorf("step field %s cannot be indexed", ref.Field)
+		return nil, err
+	}
+	ref.Path = path
+	return ref, nil
+}
+
+// parseStepPath parses a sequence of [N] indices and .key fields.
+func parseStepPath(str string) ([]interface{}, error) {
+	var path []interface{}
+	for len(str) > 0 {
+		switch str[0] {
+		case '[':
+			end := strings.Index(str, "]")
+			if end < 0 {
+				err := fmt.Errorf("unterminated index in step reference: %s", str)
+				return nil, err
+			}
+			index, err := strconv.Atoi(str[1:end])
+			if err != nil || index < 0 {
+				err := fmt.Errorf("invalid index in step reference: %s", str[:end+1])
+				return nil, err
+			}
+			path = append(path, index)
+			str = str[end+1:]
+		case '.':
+			end := strings.IndexAny(str[1:], ".[")
+			if end < 0 {
+				end = len(str) - 1
+			}
+			key := str[1 : end+1]
+			if len(key) == 0 {
+				err := errors.New("empty key in step reference")
+				return nil, err
+			}
+			path = append(path, key)
+			str = str[end+1:]
+		default:
+			err := fmt.Errorf("unexpected %q in step reference", str)
+			return nil, err
+		}
+	}
+	return path, nil
+}
+
+func (ref *StepReference) String() string {
+	str := stepPrefix + ref.Step + refDelim + string(ref.Field)
+	for _, elem := range ref.Path {
+		switch e := elem.(type) {
+		case int:
+			str += fmt.Sprintf("[%d]", e)
+		case string:
+			str += refDelim + e
+		}
+	}
+	return str
+}
+
+// Convert converts the resolved value of the reference into the param type,
+// values that are already typed are passed as-is and checked upon packing.
+func (ref *StepReference) Convert(v interface{}) (interface{}, error) {
+	if len(ref.Type) == 0 {
+		return v, nil
+	}
+	if ref.Type == ParamTypeString {
+		switch vv := v.(type) {
+		case string:
+			return vv, nil
+		case common.Address:
+			return strings.ToLower(vv.Hex()), nil
+		case *big.Int:
+			return vv.String(), nil
+		}
+		return fmt.Sprintf("%v", v), nil
+	}
+	str, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+	value, ok := parseParam(NewEvaler(), ref.Type, str)
+	if !ok {
+		err := fmt.Errorf("failed to parse %q from %s as %s", str, ref, ref.Type)
+		return nil, err
+	}
+	return value, nil
+}
+
+// StepResults provides results of the steps of a running target.
+type StepResults interface {
+	ResolveStep(ref *StepReference) (interface{}, error)
+}
+
+// WithStepResults returns a context for running the target steps that may reference earlier ones.
+func (ctx AppContext) WithStepResults(results StepResults) AppContext {
+	return AppContext{context.WithValue(ctx.Context, "steps", results)}
+}
+
+// StepResults returns results of the running target, nil if not running a target.
+func (ctx AppContext) StepResults() StepResults {
+	results, _ := ctx.Value("steps").(StepResults)
+	return results
+}
+
+// ResolveStepReference resolves the step reference against the results of the running target.
+func (ctx AppContext) ResolveStepReference(ref *StepReference) (interface{}, error) {
+	results := ctx.StepResults()
+	if results == nil {
+		err := fmt.Errorf("step reference %s can only be resolved within a target", ref)
+		return nil, err
+	}
+	v, err := results.ResolveStep(ref)
+	if err != nil {
+		return nil, err
+	}
+	return ref.Convert(v)
+}
+
+// StepReferences returns the step references used by the command.
+func (spec *Spec) StepReferences(name string) []*StepReference {
+	var refs []*StepReference
+	if cmd, ok := spec.CallCmds[name]; ok {
+		refs = collectStepReferences(refs, cmd.ParamValues())
+	} else if cmd, ok := spec.ViewCmds[name]; ok {
+		refs = collectStepReferences(refs, cmd.ParamValues())
+	} else if cmd, ok := spec.WriteCmds[name]; ok {
+		refs = collectStepReferences(refs, cmd.ParamValues())
+		refs = append(refs, cmd.Value.StepReferences()...)
+	} else if cmd, ok := spec.EventCmds[name]; ok {
+		for _, values := range cmd.FilterValues() {
+			refs = collectStepReferences(refs, values)
+		}
+	}
+	return refs
+}
+
+func collectStepReferences(refs []*StepReference, values []interface{}) []*StepReference {
+	for _, value := range values {
+		switch v := value.(type) {
+		case *StepReference:
+			refs = append(refs, v)
+		case *CompositeParam:
+			refs = collectStepReferences(refs, v.Elems)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStepReference(t *testing.T) {
+	assert := assert.New(t)
+
+	ref, err := newStepReference("#deploy-token.address", ParamTypeAddress)
+	if assert.NoError(err) {
+		assert.Equal("deploy-token", ref.Step)
+		assert.Equal(StepAddressField, ref.Field)
+		assert.Empty(ref.Path)
+		assert.Equal(ParamTypeAddress, ref.Type)
+		assert.Equal("#deploy-token.address", ref.String())
+	}
+	ref, err = newStepReference("#get-owner.result[0]", "")
+	if assert.NoError(err) {
+		assert.Equal("get-owner", ref.Step)
+		assert.Equal(StepResultField, ref.Field)
+		assert.Equal([]interface{}{0}, ref.Path)
+	}
+	ref, err = newStepReference("#get-block.result.transactions[2].hash", "")
+	if assert.NoError(err) {
+		assert.Equal([]interface{}{"transactions", 2, "hash"}, ref.Path)
+		assert.Equal("#get-block.result.transactions[2].hash", ref.String())
+	}
+
+	_, err = newStepReference("#send", "")
+	assert.Error(err)
+	_, err = newStepReference("#send.receipt", "")
+	assert.Error(err)
+	_, err = newStepReference("#send.tx[0]", "")
+	assert.Error(err)
+	_, err = newStepReference("#get-owner.result[x]", "")
+	assert.Error(err)
+	_, err = newStepReference("#get-owner.result[0", "")
+	assert.Error(err)
+	_, err = newStepReference("#get-owner.result..key", "")
+	assert.Error(err)
+}
+
+func TestStepReferenceConvert(t *testing.T) {
+	assert := assert.New(t)
+
+	addr := common.HexToAddress("0x5e2b23eeab4d0a6e79578d3479a6a37466a34a4c")
+	ref := &StepReference{Step: "deploy", Field: StepAddressField}
+	v, err := ref.Convert(addr)
+	if assert.NoError(err) {
+		assert.Equal(addr, v)
+	}
+	ref.Type = ParamTypeString
+	v, err = ref.Convert(addr)
+	if assert.NoError(err) {
+		assert.Equal("0x5e2b23eeab4d0a6e79578d3479a6a37466a34a4c", v)
+	}
+	ref = &StepReference{Step: "get-balance", Field: StepResultField, Type: ParamTypeUInt}
+	v, err = ref.Convert("0x10")
+	if assert.NoError(err) {
+		assert.Equal(big.NewInt(16), v)
+	}
+	_, err = ref.Convert("ten")
+	assert.Error(err)
+}
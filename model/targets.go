@@ -1,7 +1,9 @@
 package model
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -13,6 +15,9 @@ func (targets Targets) Validate(ctx AppContext, spec *Spec) bool {
 		"section": "Targets",
 		"func":    "Validate",
 	})
+	if !targets.validateNesting(validateLog) {
+		return false
+	}
 	for name, target := range targets {
 		if _, ok := spec.uniqueNames[name]; ok {
 			validateLog.WithField("name", name).Errorln("target name is not unique")
@@ -39,8 +44,61 @@ func (spec TargetSpec) Validate(ctx AppContext, name string, root *Spec) bool {
 		"section": "Targets",
 		"target":  "Validate",
 	})
+	if spec.IsGraph() && !spec.validateNeeds(validateLog) {
+		return false
+	}
 	for _, cmdSpec := range spec {
 		cmdName := cmdSpec.Name()
+		if len(cmdName) == 0 {
+			validateLog.Errorln("target step must specify the command to run")
+			return false
+		}
+		if len(cmdSpec.Confirmations) > 0 {
+			if _, ok := root.WriteCmds[cmdName]; !ok {
+				validateLog.WithField("command", cmdName).Errorln("only write commands await confirmations")
+				return false
+			} else if _, err := strconv.ParseUint(cmdSpec.Confirmations, 10, 64); err != nil {
+				validateLog.WithField("command", cmdName).Errorln("failed to parse confirmations")
+				return false
+			}
+		}
+		if len(cmdSpec.When) > 0 {
+			if cmdSpec.IsAwait() {
+				validateLog.Errorln("await step cannot have a condition")
+				return false
+			} else if !cmdSpec.When.Validate(ctx, root, validateLog.WithField("command", cmdName)) {
+				return false
+			}
+		}
+		if !cmdSpec.validatePolicy(root, validateLog) {
+			return false
+		}
+		if cmdSpec.IsAwait() {
+			if cmdSpec.IsDeferred() {
+				validateLog.Errorln("await step cannot be deferred")
+				return false
+			} else if cmdSpec.IsLoop() {
+				validateLog.Errorln("await step cannot be a loop")
+				return false
+			} else if root.HasCommand(TargetAwait) {
+				validateLog.WithField("command", cmdName).Errorln("command name is reserved for await steps of targets")
+				return false
+			}
+			continue
+		}
+		if cmdSpec.IsTarget(root) {
+			if !cmdSpec.validateNested(ctx, root, validateLog) {
+				return false
+			}
+			continue
+		} else if len(cmdSpec.Args) > 0 {
+			validateLog.WithField("command", cmdName).Errorln("args can only be passed to targets")
+			return false
+		}
+		// iterations are validated with copies of the command, it's validated with the context args below
+		if cmdSpec.IsLoop() && root.HasCommand(cmdName) && !cmdSpec.validateLoop(ctx, root, validateLog) {
+			return false
+		}
 		var found bool
 		if cmd, isFound := root.CallCmds[cmdName]; isFound {
 			if cmdSpec.IsDeferred() {
@@ -64,6 +122,17 @@ func (spec TargetSpec) Validate(ctx AppContext, name string, root *Spec) bool {
 			found = isFound
 			continue
 		}
+		if cmd, isFound := root.EventCmds[cmdName]; isFound {
+			if cmdSpec.IsDeferred() {
+				validateLog.WithField("command", cmdName).Errorln("event commands are deferred by default")
+				return false
+			}
+			if !cmd.Validate(ctx, cmdName, root) {
+				return false
+			}
+			found = isFound
+			continue
+		}
 		if cmd, isFound := root.WriteCmds[cmdName]; isFound {
 			if !cmd.Validate(ctx, cmdName, root) {
 				return false
@@ -76,33 +145,342 @@ func (spec TargetSpec) Validate(ctx AppContext, name string, root *Spec) bool {
 			return false
 		}
 	}
+	// commands are validated, so their step references are known
+	return spec.validateStepReferences(validateLog, root)
+}
+
+// validateNeeds ensures that steps of a graph target are unique and need only the other steps of the target,
+// without cycles. Deferred and await steps are not used, since the steps run as soon as their needs are done.
+func (spec TargetSpec) validateNeeds(validateLog *log.Entry) bool {
+	steps := make(map[string]TargetCommandSpec, len(spec))
+	for _, cmdSpec := range spec {
+		cmdName := cmdSpec.Name()
+		if cmdSpec.IsAwait() || cmdSpec.IsDeferred() {
+			validateLog.WithField("command", cmdName).Errorln("steps with needs run concurrently, no await or deferred steps allowed")
+			return false
+		} else if _, ok := steps[cmdName]; ok {
+			validateLog.WithField("command", cmdName).Errorln("step is not unique in the target with needs")
+			return false
+		}
+		steps[cmdName] = cmdSpec
+	}
+	for _, cmdSpec := range spec {
+		for _, need := range cmdSpec.Needs {
+			if _, ok := steps[need]; !ok {
+				validateLog.WithFields(log.Fields{
+					"command": cmdSpec.Name(),
+					"need":    need,
+				}).Errorln("needed step is not found in the target")
+				return false
+			}
+		}
+	}
+	// depth-first search for cycles
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(spec))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			validateLog.WithField("command", name).Errorln("steps of the target need each other in a cycle")
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		for _, need := range steps[name].Needs {
+			if !visit(need) {
+				return false
+			}
+		}
+		state[name] = visited
+		return true
+	}
+	for _, cmdSpec := range spec {
+		if !visit(cmdSpec.Name()) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsGraph reports whether any step of the target needs other steps, then the steps are run as a dependency graph,
+// instead of one after another.
+func (spec TargetSpec) IsGraph() bool {
+	for _, cmdSpec := range spec {
+		if len(cmdSpec.Needs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// availableSteps returns the steps which results are available to each step: the earlier steps,
+// or the steps needed directly or transitively in a graph target.
+func (spec TargetSpec) availableSteps() map[string]map[string]struct{} {
+	available := make(map[string]map[string]struct{}, len(spec))
+	if !spec.IsGraph() {
+		earlier := make(map[string]struct{}, len(spec))
+		for _, cmdSpec := range spec {
+			steps := make(map[string]struct{}, len(earlier))
+			for name := range earlier {
+				steps[name] = struct{}{}
+			}
+			if _, ok := available[cmdSpec.Name()]; !ok {
+				// references of a repeated step are the same, the first one is the strictest
+				available[cmdSpec.Name()] = steps
+			}
+			earlier[cmdSpec.Name()] = struct{}{}
+		}
+		return available
+	}
+	needs := make(map[string][]string, len(spec))
+	for _, cmdSpec := range spec {
+		needs[cmdSpec.Name()] = cmdSpec.Needs
+	}
+	for _, cmdSpec := range spec {
+		steps := make(map[string]struct{})
+		queue := append([]string{}, cmdSpec.Needs...)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if _, ok := steps[name]; ok {
+				continue
+			}
+			steps[name] = struct{}{}
+			queue = append(queue, needs[name]...)
+		}
+		available[cmdSpec.Name()] = steps
+	}
+	return available
+}
+
+// validateStepReferences ensures that steps reference only the earlier steps (or the needed ones),
+// with the fields they can provide.
+func (spec TargetSpec) validateStepReferences(validateLog *log.Entry, root *Spec) bool {
+	available := spec.availableSteps()
+	for _, cmdSpec := range spec {
+		cmdName := cmdSpec.Name()
+		for _, ref := range root.StepReferences(cmdName) {
+			refLog := validateLog.WithFields(log.Fields{
+				"command":   cmdName,
+				"reference": ref.String(),
+			})
+			if _, isTarget := root.Targets[ref.Step]; isTarget {
+				refLog.Errorln("targets have no results to reference")
+				return false
+			} else if _, ok := available[cmdName][ref.Step]; !ok {
+				if spec.IsGraph() {
+					refLog.Errorln("step reference must refer to a step needed by the step")
+					return false
+				}
+				refLog.Errorln("step reference must refer to an earlier step of the target")
+				return false
+			}
+			switch ref.Field {
+			case StepAddressField:
+				if _, ok := root.CallCmds[ref.Step]; ok {
+					refLog.Errorln("call commands have no address")
+					return false
+				}
+			case StepTxField:
+				if _, ok := root.WriteCmds[ref.Step]; !ok {
+					refLog.Errorln("only write commands send transactions")
+					return false
+				}
+			}
+		}
+		// conditions may also use results of VIEW and CALL commands, which are run on demand,
+		// and addresses of instances of WRITE commands
+		for _, ref := range cmdSpec.When.StepReferences() {
+			if _, isTarget := root.Targets[ref.Step]; isTarget {
+				validateLog.WithFields(log.Fields{
+					"command":   cmdName,
+					"reference": ref.String(),
+				}).Errorln("targets have no results to reference")
+				return false
+			} else if _, ok := available[cmdName][ref.Step]; ok {
+				continue
+			}
+			_, isCall := root.CallCmds[ref.Step]
+			_, isView := root.ViewCmds[ref.Step]
+			_, isWrite := root.WriteCmds[ref.Step]
+			if (isCall || isView) && ref.Field == StepResultField {
+				continue
+			} else if isWrite && ref.Field == StepAddressField {
+				continue
+			}
+			validateLog.WithFields(log.Fields{
+				"command":   cmdName,
+				"reference": ref.String(),
+			}).Errorln("condition must refer to an available step, a result of VIEW or CALL command, or an address of WRITE command")
+			return false
+		}
+	}
 	return true
 }
 
 func (spec TargetSpec) CmdNames() []string {
 	names := make([]string, 0, len(spec))
 	for _, cmd := range spec {
+		if cmd.IsAwait() {
+			continue
+		}
 		names = append(names, cmd.Name())
 	}
 	return names
 }
 
+// ArgCount returns the number of args used by the commands of the target, and the targets it forwards the args to.
 func (spec TargetSpec) ArgCount(root *Spec) int {
 	set := make(map[int]struct{})
-	for _, cmd := range spec {
-		root.CountArgsUsing(set, cmd.Name())
+	for _, target := range root.expandTarget(spec, true) {
+		for _, cmd := range target {
+			if !cmd.IsTarget(root) {
+				root.CountArgsUsing(set, cmd.Name())
+				continue
+			}
+			for _, v := range cmd.Args {
+				if str := nillableStr(v); isArgRef(str) {
+					if argID, err := argReferenceID(str); err == nil && argID > 0 {
+						set[argID] = struct{}{}
+					}
+				}
+			}
+		}
 	}
 	return len(set)
 }
 
-type TargetCommandSpec string
+// TargetCommandSpec is a step of target, either the command name (with "&" suffix, if deferred),
+// or a mapping with the command name under run and the options of the step.
+type TargetCommandSpec struct {
+	Run string `yaml:"run"`
+	// overrides confirmations of CONFIG for the transaction of the step
+	Confirmations string `yaml:"confirmations"`
+	// steps that must be done before the step, the target is run as a dependency graph then
+	Needs []string `yaml:"needs"`
+	// the step is skipped, unless the condition is true
+	When Condition `yaml:"when"`
+	// the command is run for each value, or each combination of values of the matrix variables
+	ForEach *TargetLoopSpec          `yaml:"for_each"`
+	Matrix  map[string][]interface{} `yaml:"matrix"`
+	// args of the target invoked by the step, the args are forwarded as-is if not specified
+	Args []interface{} `yaml:"args"`
+	// the failed step is retried, after the backoff doubled for each retry
+	Retries string `yaml:"retries"`
+	Backoff string `yaml:"backoff"`
+	// overrides awaitTimeout of CONFIG for the transaction of the step
+	Timeout string `yaml:"timeout"`
+	// failure of the step doesn't stop the target
+	IgnoreErrors bool `yaml:"ignore_errors"`
+}
+
+func (spec *TargetCommandSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var run string
+	if err := unmarshal(&run); err == nil {
+		spec.Run = run
+		return nil
+	}
+	type plain TargetCommandSpec
+	return unmarshal((*plain)(spec))
+}
 
 const targetCommandDefer = "&"
 
+// TargetAwait is a barrier step of target, it waits for the deferred transactions sent before it.
+const TargetAwait = "await"
+
 func (spec TargetCommandSpec) Name() string {
-	return strings.TrimSpace(strings.TrimSuffix(string(spec), targetCommandDefer))
+	return strings.TrimSpace(strings.TrimSuffix(spec.Run, targetCommandDefer))
 }
 
 func (spec TargetCommandSpec) IsDeferred() bool {
-	return strings.HasSuffix(string(spec), targetCommandDefer)
+	return strings.HasSuffix(spec.Run, targetCommandDefer)
+}
+
+func (spec TargetCommandSpec) IsAwait() bool {
+	return spec.Name() == TargetAwait
+}
+
+// ConfirmationsInt returns the number of confirmations to await for the transaction of the step.
+func (spec TargetCommandSpec) ConfirmationsInt(config *ConfigSpec) uint64 {
+	if len(spec.Confirmations) > 0 {
+		if confirmations, err := strconv.ParseUint(spec.Confirmations, 10, 64); err == nil {
+			return confirmations
+		}
+	}
+	return config.ConfirmationsInt()
+}
+
+const defaultStepBackoff = time.Second
+
+// RetriesInt returns the number of retries of the failed step.
+func (spec TargetCommandSpec) RetriesInt() int {
+	retries, _ := strconv.Atoi(spec.Retries)
+	return retries
+}
+
+// BackoffDuration returns the delay before the first retry of the step, it's doubled for each next retry.
+func (spec TargetCommandSpec) BackoffDuration() time.Duration {
+	if backoff, err := time.ParseDuration(spec.Backoff); err == nil {
+		return backoff
+	}
+	return defaultStepBackoff
+}
+
+// AwaitTimeoutDuration returns the timeout of awaiting the transaction of the step.
+func (spec TargetCommandSpec) AwaitTimeoutDuration(config *ConfigSpec) time.Duration {
+	if timeout, err := time.ParseDuration(spec.Timeout); err == nil {
+		return timeout
+	}
+	timeout, _ := config.AwaitTimeoutDuration()
+	return timeout
+}
+
+// validatePolicy checks the retry, timeout and error policies of the step.
+func (spec TargetCommandSpec) validatePolicy(root *Spec, validateLog *log.Entry) bool {
+	stepLog := validateLog.WithField("command", spec.Name())
+	_, isWrite := root.WriteCmds[spec.Name()]
+	if spec.IsAwait() {
+		if len(spec.Retries) > 0 || len(spec.Backoff) > 0 || len(spec.Timeout) > 0 || spec.IgnoreErrors {
+			validateLog.Errorln("await step cannot have retry, timeout or error policies")
+			return false
+		}
+		return true
+	}
+	if len(spec.Retries) > 0 {
+		if _, err := strconv.ParseUint(spec.Retries, 10, 16); err != nil {
+			stepLog.WithError(err).Errorln("failed to parse retries")
+			return false
+		} else if spec.IsTarget(root) {
+			stepLog.Errorln("targets are not retried, but their steps can be")
+			return false
+		} else if isWrite && spec.IsLoop() {
+			stepLog.Errorln("loops of write commands are not retried, the done iterations would be sent again")
+			return false
+		}
+	}
+	if len(spec.Backoff) > 0 {
+		if len(spec.Retries) == 0 {
+			stepLog.Errorln("backoff is used only with retries")
+			return false
+		} else if _, err := time.ParseDuration(spec.Backoff); err != nil {
+			stepLog.WithError(err).Errorln("failed to parse backoff")
+			return false
+		}
+	}
+	if len(spec.Timeout) > 0 {
+		if !isWrite {
+			stepLog.Errorln("only write commands await transactions")
+			return false
+		} else if _, err := time.ParseDuration(spec.Timeout); err != nil {
+			stepLog.WithError(err).Errorln("failed to parse timeout")
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IsTarget reports whether the step invokes another target.
+func (spec TargetCommandSpec) IsTarget(root *Spec) bool {
+	_, ok := root.Targets[spec.Name()]
+	return ok
+}
+
+// validateNesting ensures that targets don't invoke each other in a cycle.
+func (targets Targets) validateNesting(validateLog *log.Entry) bool {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(targets))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			validateLog.WithField("target", name).Errorln("targets invoke each other in a cycle")
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		for _, cmdSpec := range targets[name] {
+			if _, ok := targets[cmdSpec.Name()]; !ok {
+				continue
+			}
+			if !visit(cmdSpec.Name()) {
+				return false
+			}
+		}
+		state[name] = visited
+		return true
+	}
+	for name := range targets {
+		if !visit(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateNested validates the step invoking a target. If the step passes args to the target,
+// the target is validated with them, otherwise the args are forwarded as-is.
+func (spec TargetCommandSpec) validateNested(ctx AppContext, root *Spec, validateLog *log.Entry) bool {
+	name := spec.Name()
+	nestedLog := validateLog.WithField("command", name)
+	if spec.IsDeferred() {
+		nestedLog.Errorln("targets cannot be deferred")
+		return false
+	} else if spec.IsLoop() {
+		nestedLog.Errorln("targets cannot be run in loops")
+		return false
+	} else if len(spec.Args) == 0 {
+		return true
+	}
+	args, resolved, err := spec.nestedArgs(ctx)
+	if err != nil {
+		nestedLog.WithError(err).Errorln("failed to resolve args of the target")
+		return false
+	} else if !resolved {
+		// args are checked once provided
+		return true
+	}
+	nestedCtx := spec.nestedContext(ctx, root, args)
+	if argSpecs, err := root.ArgSpecs(name); err == nil && !argSpecs.ValidateValues(nestedCtx, nestedLog, root) {
+		return false
+	}
+	nested := root.Targets[name]
+	if !nested.Validate(nestedCtx, name, root) {
+		return false
+	}
+	// commands get the values of the context args back
+	return nested.Validate(ctx, name, root)
+}
+
+// NestedContext returns the context of the target invoked by the step, with the args passed by the step,
+// resolved against the context. Without args of the step, the context args are forwarded as-is.
+func (spec TargetCommandSpec) NestedContext(ctx AppContext, root *Spec) (AppContext, error) {
+	if len(spec.Args) == 0 {
+		return ctx, nil
+	}
+	args, resolved, err := spec.nestedArgs(ctx)
+	if err != nil {
+		return ctx, err
+	} else if !resolved {
+		err := errors.New("insufficient arguments provided")
+		return ctx, err
+	}
+	return spec.nestedContext(ctx, root, args), nil
+}
+
+func (spec TargetCommandSpec) nestedContext(ctx AppContext, root *Spec, args []string) AppContext {
+	argSpecs, _ := root.ArgSpecs(spec.Name())
+	return ctx.WithArgs(args).WithArgNames(argSpecs.Positions())
+}
+
+// nestedArgs resolves the args passed by the step to the target, references to the args of the context
+// are substituted. Returns false if the context has not enough args.
+func (spec TargetCommandSpec) nestedArgs(ctx AppContext) ([]string, bool, error) {
+	args := []string{spec.Name()}
+	for _, v := range spec.Args {
+		value := nillableStr(v)
+		if isArgRef(value) {
+			ref, err := newArgReference(ctx, value)
+			if err != nil {
+				return nil, false, err
+			} else if ref.ArgID < 0 {
+				return nil, false, nil
+			}
+			value = ctx.AppCommandArgs()[ref.ArgID]
+		}
+		args = append(args, value)
+	}
+	return args, true, nil
+}
+
+// expandTarget returns the target along with the targets it invokes, transitively. If forwardedOnly is set,
+// only the targets that get the args forwarded as-is are followed. Cycles are reported upon validation,
+// here a target is just not followed twice.
+func (spec *Spec) expandTarget(target TargetSpec, forwardedOnly bool) []TargetSpec {
+	targets := []TargetSpec{target}
+	followed := make(map[string]struct{})
+	for i := 0; i < len(targets); i++ {
+		for _, cmdSpec := range targets[i] {
+			name := cmdSpec.Name()
+			nested, ok := spec.Targets[name]
+			if !ok || (forwardedOnly && len(cmdSpec.Args) > 0) {
+				continue
+			} else if _, ok := followed[name]; ok {
+				continue
+			}
+			followed[name] = struct{}{}
+			targets = append(targets, nested)
+		}
+	}
+	return targets
+}
+
+// TargetCmdNames returns the names of commands run by the target, including the commands of the targets it invokes.
+func (spec *Spec) TargetCmdNames(name string) []string {
+	target, ok := spec.Targets[name]
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, target := range spec.expandTarget(target, false) {
+		for _, cmdName := range target.CmdNames() {
+			if _, isTarget := spec.Targets[cmdName]; !isTarget {
+				names = append(names, cmdName)
+			}
+		}
+	}
+	return names
+}
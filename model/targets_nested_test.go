@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetNesting(t *testing.T) {
+	assert := assert.New(t)
+
+	validateLog := log.WithField("test", "TestTargetNesting")
+	root := &Spec{
+		CallCmds: CallCmds{
+			"txinfo": &CallCmdSpec{Args: ArgSpecs{{Name: "hash"}}},
+			"block":  &CallCmdSpec{},
+		},
+		Targets: Targets{
+			"view": TargetSpec{{Run: "txinfo"}, {Run: "block"}},
+			"full": TargetSpec{
+				{Run: "view"},
+				{Run: "view", Args: []interface{}{"$2"}},
+				{Run: "block"},
+			},
+			"top": TargetSpec{{Run: "full"}},
+		},
+	}
+	assert.True(root.Targets.validateNesting(validateLog))
+	assert.True(root.Targets["full"][0].IsTarget(root))
+	assert.False(root.Targets["full"][2].IsTarget(root))
+	assert.Len(root.expandTarget(root.Targets["top"], false), 3)
+	assert.Equal([]string{"block", "txinfo", "block"}, root.TargetCmdNames("top"))
+
+	argSpecs, err := root.ArgSpecs("top")
+	assert.NoError(err)
+	assert.Equal(map[string]int{"hash": 1}, argSpecs.Positions())
+	assert.Equal(2, root.Targets["top"].ArgCount(root))
+
+	ctx := NewAppContext(context.Background(), "full", []string{"full", "0x01", "0x02"},
+		"genesis", "", "", nil, nil)
+	nestedCtx, err := root.Targets["full"][1].NestedContext(ctx, root)
+	assert.NoError(err)
+	assert.Equal([]string{"view", "0x02"}, nestedCtx.AppCommandArgs())
+	forwardedCtx, err := root.Targets["full"][0].NestedContext(ctx, root)
+	assert.NoError(err)
+	assert.Equal(ctx.AppCommandArgs(), forwardedCtx.AppCommandArgs())
+	shortCtx := NewAppContext(context.Background(), "full", []string{"full", "0x01"},
+		"genesis", "", "", nil, nil)
+	_, err = root.Targets["full"][1].NestedContext(shortCtx, root)
+	assert.Error(err)
+
+	root.Targets["view"] = append(root.Targets["view"], TargetCommandSpec{Run: "top"})
+	assert.False(root.Targets.validateNesting(validateLog))
+	assert.False(Targets{"self": TargetSpec{{Run: "self"}}}.validateNesting(validateLog))
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	yaml "github.com/xlab/yamlx"
+)
+
+func TestTargetAwait(t *testing.T) {
+	assert := assert.New(t)
+
+	target := TargetSpec{{Run: "send-to-alice &"}, {Run: "send-to-bob &"}, {Run: "await"}, {Run: "balances"}}
+	assert.True(target[0].IsDeferred())
+	assert.False(target[0].IsAwait())
+	assert.True(target[2].IsAwait())
+	assert.False(target[2].IsDeferred())
+	assert.True(TargetCommandSpec{Run: "await &"}.IsAwait())
+	assert.Equal([]string{"send-to-alice", "send-to-bob", "balances"}, target.CmdNames())
+}
+
+func TestTargetConfirmations(t *testing.T) {
+	assert := assert.New(t)
+
+	var target TargetSpec
+	err := yaml.Unmarshal([]byte(`
+- deploy-token &
+- run: mint
+  confirmations: 6
+- await
+`), &target)
+	assert.NoError(err)
+	assert.Len(target, 3)
+	assert.True(target[0].IsDeferred())
+	assert.Equal("mint", target[1].Name())
+	assert.True(target[2].IsAwait())
+
+	config := &ConfigSpec{}
+	assert.True(config.Validate())
+	assert.EqualValues(1, target[0].ConfirmationsInt(config))
+	assert.EqualValues(6, target[1].ConfirmationsInt(config))
+	config.Confirmations = "3"
+	assert.EqualValues(3, target[0].ConfirmationsInt(config))
+}
+
+func TestTargetNeeds(t *testing.T) {
+	assert := assert.New(t)
+
+	validateLog := log.WithField("test", "TestTargetNeeds")
+	target := TargetSpec{
+		{Run: "deploy-token"},
+		{Run: "deploy-registry"},
+		{Run: "register", Needs: []string{"deploy-token", "deploy-registry"}},
+		{Run: "mint", Needs: []string{"register"}},
+	}
+	assert.True(target.IsGraph())
+	assert.True(target.validateNeeds(validateLog))
+	available := target.availableSteps()
+	assert.Empty(available["deploy-token"])
+	assert.Len(available["register"], 2)
+	assert.Len(available["mint"], 3)
+
+	sequential := TargetSpec{{Run: "deploy-token"}, {Run: "mint"}}
+	assert.False(sequential.IsGraph())
+	assert.Len(sequential.availableSteps()["mint"], 1)
+
+	cycle := TargetSpec{
+		{Run: "a", Needs: []string{"c"}},
+		{Run: "b", Needs: []string{"a"}},
+		{Run: "c", Needs: []string{"b"}},
+	}
+	assert.False(cycle.validateNeeds(validateLog))
+	assert.False(TargetSpec{{Run: "a", Needs: []string{"missing"}}}.validateNeeds(validateLog))
+	assert.False(TargetSpec{{Run: "a"}, {Run: "b &", Needs: []string{"a"}}}.validateNeeds(validateLog))
+	assert.False(TargetSpec{{Run: "a"}, {Run: "a", Needs: []string{"a"}}}.validateNeeds(validateLog))
+}
+
+func TestTargetPolicies(t *testing.T) {
+	assert := assert.New(t)
+
+	validateLog := log.WithField("test", "TestTargetPolicies")
+	root := &Spec{
+		Config: &ConfigSpec{AwaitTimeout: "10m"},
+		CallCmds: CallCmds{
+			"balance": &CallCmdSpec{},
+		},
+		WriteCmds: WriteCmds{
+			"mint": &WriteCmdSpec{},
+		},
+		Targets: Targets{
+			"view": TargetSpec{{Run: "balance"}},
+		},
+	}
+	var target TargetSpec
+	err := yaml.Unmarshal([]byte(`
+- run: balance
+  retries: 3
+  backoff: 500ms
+- run: mint
+  timeout: 2m
+  ignore_errors: true
+- mint
+`), &target)
+	if !assert.NoError(err) {
+		return
+	}
+	for _, cmdSpec := range target {
+		assert.True(cmdSpec.validatePolicy(root, validateLog))
+	}
+	assert.Equal(3, target[0].RetriesInt())
+	assert.Equal(500*time.Millisecond, target[0].BackoffDuration())
+	assert.True(target[1].IgnoreErrors)
+	assert.Equal(2*time.Minute, target[1].AwaitTimeoutDuration(root.Config))
+	assert.Equal(0, target[2].RetriesInt())
+	assert.Equal(defaultStepBackoff, target[2].BackoffDuration())
+	assert.Equal(10*time.Minute, target[2].AwaitTimeoutDuration(root.Config))
+
+	assert.False(TargetCommandSpec{Run: "balance", Retries: "-1"}.validatePolicy(root, validateLog))
+	assert.False(TargetCommandSpec{Run: "balance", Backoff: "1s"}.validatePolicy(root, validateLog))
+	assert.False(TargetCommandSpec{Run: "balance", Timeout: "1m"}.validatePolicy(root, validateLog))
+	assert.False(TargetCommandSpec{Run: "view", Retries: "1"}.validatePolicy(root, validateLog))
+	assert.False(TargetCommandSpec{Run: "await", IgnoreErrors: true}.validatePolicy(root, validateLog))
+	assert.False(TargetCommandSpec{
+		Run:     "mint",
+		Retries: "1",
+		ForEach: &TargetLoopSpec{Values: []interface{}{1}},
+	}.validatePolicy(root, validateLog))
+	assert.True(TargetCommandSpec{Run: "view", IgnoreErrors: true}.validatePolicy(root, validateLog))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"regexp"
 	"strings"
 
 	"github.com/AtlantPlatform/ethfw"
@@ -38,6 +39,22 @@ func (v Valuer) Parse(ctx AppContext, root *Spec, additionalDenominators []strin
 			}
 			valueStrParts[i] = ctx.AppCommandArgs()[ref.ArgID]
 		}
+		if isStepRef(part) {
+			ref, err := newStepReference(part, "")
+			if err != nil {
+				return nil, err
+			}
+			v, err := ctx.ResolveStepReference(ref)
+			if err != nil {
+				return nil, err
+			}
+			switch vv := v.(type) {
+			case *big.Int:
+				valueStrParts[i] = vv.String()
+			default:
+				valueStrParts[i] = fmt.Sprintf("%v", vv)
+			}
+		}
 	}
 	valueStr = strings.Join(valueStrParts, " ")
 
@@ -90,6 +107,35 @@ func (v Valuer) CountArgsUsing(set map[int]struct{}) {
 	}
 }
 
+// StepReferences returns references to results of earlier target steps used in the value.
+func (v Valuer) StepReferences() []*StepReference {
+	var refs []*StepReference
+	for _, part := range strings.Split(string(v), " ") {
+		if isStepRef(part) {
+			if ref, err := newStepReference(part, ""); err == nil {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// TokenDenominator returns the token symbol the value is denominated in, e.g. TKN of 100 TKN.
+// Common denominations, such as eth or gwei, are not token symbols.
+func (v Valuer) TokenDenominator() (string, bool) {
+	parts := strings.Fields(string(v))
+	if len(parts) < 2 {
+		return "", false
+	}
+	den := strings.ToLower(parts[len(parts)-1])
+	if IsCommonDenominator(den) || !tokenSymbolRx.MatchString(den) {
+		return "", false
+	}
+	return den, true
+}
+
+var tokenSymbolRx = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
 type ExtendedValue struct {
 	Value       *big.Int
 	ValueWei    *ethfw.Wei
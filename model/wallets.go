@@ -36,7 +36,7 @@ func (wallets Wallets) Validate(ctx AppContext, spec *Spec) bool {
 
 func (wallets Wallets) NameOf(address string) string {
 	for name, wallet := range wallets {
-		if wallet.Address == address {
+		if strings.EqualFold(wallet.Address, address) {
 			return name
 		}
 	}
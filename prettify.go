@@ -3,10 +3,13 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/AtlantPlatform/ethereum-playbook/executor"
 )
 
 func prettifyValue(v interface{}) interface{} {
@@ -59,7 +62,38 @@ func prettifyValue(v interface{}) interface{} {
 	case nil:
 		return nil
 	default:
-		return fmt.Sprintf("%v (%T)", vv, vv)
+		return prettifyComposite(v)
+	}
+}
+
+// prettifyComposite formats arrays and tuples returned from contract calls,
+// byte arrays are formatted as hex strings.
+func prettifyComposite(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, rv.Len())
+			for i := range buf {
+				buf[i] = byte(rv.Index(i).Uint())
+			}
+			return hexutil.Encode(buf)
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = prettifyValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Struct:
+		container := make(map[string]interface{})
+		for i := 0; i < rv.NumField(); i++ {
+			if field := rv.Type().Field(i); len(field.PkgPath) == 0 {
+				container[field.Name] = prettifyValue(rv.Field(i).Interface())
+			}
+		}
+		return container
+	default:
+		return fmt.Sprintf("%v (%T)", v, v)
 	}
 }
 
@@ -81,3 +115,122 @@ func prettify(v interface{}) interface{} {
 		return prettifyValue(v)
 	}
 }
+
+// prettifyResult formats the command result, results of mined
+// WRITE commands also include the decoded events.
+func prettifyResult(result *executor.CommandResult) interface{} {
+	if sim, ok := result.Result.(*executor.Simulation); ok {
+		return prettifySimulation(sim)
+	} else if status, ok := result.Result.(*executor.TxStatus); ok {
+		return prettifyTxStatus(status, result.Events)
+	} else if deployment, ok := result.Result.(*executor.Deployment); ok {
+		return prettifyDeployment(deployment, result.Events)
+	} else if notSimulated, ok := result.Result.(*executor.NotSimulated); ok {
+		return map[string]interface{}{
+			"dryRun":  true,
+			"to":      prettifyValue(notSimulated.Address),
+			"skipped": notSimulated.String(),
+		}
+	} else if skipped, ok := result.Result.(*executor.Skipped); ok {
+		return map[string]interface{}{
+			"skipped": true,
+			"when":    skipped.Condition,
+		}
+	} else if result.Result == nil && result.Events != nil {
+		// EVENTS command
+		return prettifyEvents(result.Events)
+	} else if len(result.Events) == 0 {
+		return prettify(result.Result)
+	}
+	return map[string]interface{}{
+		"tx":     prettify(result.Result),
+		"events": prettifyEvents(result.Events),
+	}
+}
+
+// prettifySimulation formats the outcome of a WRITE command simulated in dry-run mode.
+func prettifySimulation(sim *executor.Simulation) interface{} {
+	container := map[string]interface{}{
+		"dryRun":   true,
+		"from":     prettifyValue(sim.From),
+		"nonce":    sim.Nonce,
+		"gas":      sim.Gas,
+		"gasPrice": prettifyValue(sim.GasPrice),
+		"fee":      prettifyValue(sim.Fee),
+	}
+	if sim.To != nil {
+		container["to"] = prettifyValue(*sim.To)
+		container["return"] = hexutil.Encode(sim.ReturnData)
+	}
+	if sim.ContractAddress != nil {
+		container["address"] = prettifyValue(*sim.ContractAddress)
+	}
+	return container
+}
+
+// prettifyTxStatus formats the final status of a deferred transaction awaited by the target.
+func prettifyTxStatus(status *executor.TxStatus, events []*executor.Event) interface{} {
+	container := map[string]interface{}{
+		"tx":      strings.ToLower(status.TxHash.Hex()),
+		"status":  "success",
+		"block":   status.Block,
+		"gasUsed": status.GasUsed,
+	}
+	if !status.Success {
+		container["status"] = "failed"
+	}
+	if status.ContractAddress != nil {
+		container["address"] = prettifyValue(*status.ContractAddress)
+	}
+	if status.Error != nil {
+		container["error"] = status.Error.Error()
+	}
+	if len(events) > 0 {
+		container["events"] = prettifyEvents(events)
+	}
+	return container
+}
+
+// prettifyDeployment formats the result of a WRITE command that has deployed a contract instance,
+// the block and gas used are known once mined.
+func prettifyDeployment(deployment *executor.Deployment, events []*executor.Event) interface{} {
+	container := map[string]interface{}{
+		"contract": deployment.Contract,
+		"address":  prettifyValue(deployment.Address),
+		"tx":       strings.ToLower(deployment.TxHash.Hex()),
+	}
+	if deployment.Block > 0 {
+		container["block"] = deployment.Block
+		container["gasUsed"] = deployment.GasUsed
+	}
+	if len(deployment.Symbol) > 0 {
+		container["symbol"] = deployment.Symbol
+	}
+	if len(events) > 0 {
+		container["events"] = prettifyEvents(events)
+	}
+	return container
+}
+
+func prettifyEvents(events []*executor.Event) []interface{} {
+	formatted := make([]interface{}, len(events))
+	for i, ev := range events {
+		args := make(map[string]interface{}, len(ev.Args))
+		for _, arg := range ev.Args {
+			args[arg.Name] = prettifyValue(arg.Value)
+		}
+		container := map[string]interface{}{
+			"event":    ev.Signature(),
+			"contract": ev.Contract,
+			"address":  strings.ToLower(ev.Address.Hex()),
+			"args":     args,
+			"block":    ev.Block,
+			"tx":       strings.ToLower(ev.TxHash.Hex()),
+		}
+		if ev.Instance >= 0 {
+			container["instance"] = ev.Instance
+		}
+		formatted[i] = container
+	}
+	return formatted
+}